@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/export"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/migration"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/services"
+)
+
+//set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+//command is one subcommand of the binary, run returns the process exit code
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "start the HTTP server (default)", runServe},
+		{"check-config", "validate the configuration and exit", runCheckConfig},
+		{"routes", "print every registered path with its decoder and endpoint", runRoutes},
+		{"migrate", "apply pending schema migrations to a datasource", runMigrate},
+		{"export", "write orders or customers as csv or xlsx, e.g. export orders -format xlsx -out orders.xlsx", runExport},
+		{"duplicates", "list customers that are likely duplicates of each other, best matches first", runDuplicates},
+		{"version", "print build information", runVersion},
+		{"help", "print this help", func([]string) int { usage(); return 0 }},
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [flags]\n\ncommands:\n", filepath.Base(os.Args[0]))
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s%s\n", c.name, c.usage)
+	}
+	fmt.Fprintf(os.Stderr, "\nrun %s <command> -h for the flags of a command\n", filepath.Base(os.Args[0]))
+}
+
+//newFlagSet returns the flags shared by every command reading the configuration
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	configFile := fs.String("conf", "conf-dev.yml", "main configuration file, empty to configure from environment and flags only")
+	cm.RegisterFlags(fs)
+	return fs, configFile
+}
+
+func runCheckConfig(args []string) int {
+	fs, configFile := newFlagSet("check-config")
+	fs.Parse(args)
+
+	return checkConfiguration(*configFile)
+}
+
+func runRoutes(args []string) int {
+	fs, configFile := newFlagSet("routes")
+	fs.Parse(args)
+
+	c, err := cm.LoadConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	printRoutes(os.Stdout, c.RootURL)
+	return 0
+}
+
+func runMigrate(args []string) int {
+	fs, configFile := newFlagSet("migrate")
+	dir := fs.String("dir", "migrations", "directory holding one sub directory of migrations per datasource")
+	name := fs.String("datasource", "", "datasource to migrate, defaults to defaultDatasource")
+	dryRun := fs.Bool("dry-run", false, "only list the pending migrations")
+	fs.Parse(args)
+
+	c, err := cm.LoadConfig(*configFile)
+	if err == nil {
+		err = c.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *name == "" {
+		*name = c.DefaultDatasource
+	}
+	ds, ok := c.Datasources[*name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown datasource %q\n", *name)
+		return 1
+	}
+
+	migrations, err := migration.Load(filepath.Join(*dir, *name))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	db := ds
+	if db.Db, err = db.Open(); err != nil {
+		fmt.Fprintf(os.Stderr, "datasource %s: %v\n", *name, err)
+		return 1
+	}
+	defer db.Close()
+
+	pending, err := migration.Pending(&db, migrations)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if len(pending) == 0 {
+		fmt.Printf("%s: schema is up to date\n", *name)
+		return 0
+	}
+
+	for _, m := range pending {
+		if *dryRun {
+			fmt.Printf("%s: pending %s_%s\n", *name, m.Version, m.Name)
+			continue
+		}
+		if err := migration.Apply(&db, m); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *name, err)
+			return 1
+		}
+		fmt.Printf("%s: applied %s_%s\n", *name, m.Version, m.Name)
+	}
+
+	return 0
+}
+
+//runExport takes what to export as first argument, the filters are those of the listing endpoints
+func runExport(args []string) int {
+	fs, configFile := newFlagSet("export")
+	format := fs.String("format", cm.FormatCSV, "csv or xlsx")
+	out := fs.String("out", "", "file to write, standard output when empty")
+
+	var orders cm.OrderFilter
+	fs.StringVar(&orders.CustomerID, "customerID", "", "orders of this customer only")
+	fs.StringVar(&orders.EmployeeID, "employeeID", "", "orders of this employee only")
+	fs.StringVar(&orders.ProductID, "productID", "", "orders with a line of this product only")
+	fs.StringVar(&orders.Status, "status", "", "orders in this status only, e.g. paid")
+	fs.StringVar(&orders.DateFrom, "dateFrom", "", "orders placed on or after this day, 2006-01-02")
+	fs.StringVar(&orders.DateTo, "dateTo", "", "orders placed on or before this day, 2006-01-02")
+	fs.StringVar(&orders.Sort, "sort", "", "orderID, orderDate, customerID or employeeID, prefixed with - for descending")
+
+	var customers cm.CustomerFilter
+	fs.StringVar(&customers.Country, "country", "", "customers of this country only")
+	fs.StringVar(&customers.City, "city", "", "customers of this city only")
+
+	what := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		what, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+
+	if what != "orders" && what != "customers" {
+		fmt.Fprintln(os.Stderr, "export needs orders or customers as first argument")
+		return 2
+	}
+
+	c, err := cm.LoadConfig(*configFile)
+	if err == nil {
+		err = c.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	pools, err := initDatabase(c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeDatabase(pools)
+
+	svc := services.NewPaymentService(pools, c)
+
+	var e cm.Export
+	if what == "orders" {
+		e = svc.ExportOrdersHandler(context.Background(), cm.OrderExport{Format: *format, OrderFilter: orders})
+	} else {
+		e = svc.ExportCustomersHandler(context.Background(), cm.CustomerExport{Format: *format, CustomerFilter: customers})
+	}
+	if e.Code != cm.CodeSuccess {
+		fmt.Fprintln(os.Stderr, e.Remark)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	var f *os.File
+	if *out != "" {
+		if f, err = os.Create(*out); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		w = f
+	}
+
+	err = export.Write(e, w)
+	//a failed close loses what the file system had not written yet
+	if f != nil {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export %s: %v\n", what, err)
+		return 1
+	}
+	return 0
+}
+
+func runDuplicates(args []string) int {
+	fs, configFile := newFlagSet("duplicates")
+
+	var req cm.DuplicateSearch
+	fs.Float64Var(&req.MinScore, "minScore", 0, "lowest score reported, from 0 to 1, 0 for the default")
+	fs.StringVar(&req.Country, "country", "", "compare customers of this country only")
+	fs.StringVar(&req.City, "city", "", "compare customers of this city only")
+	fs.Parse(args)
+
+	c, err := cm.LoadConfig(*configFile)
+	if err == nil {
+		err = c.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	pools, err := initDatabase(c)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer closeDatabase(pools)
+
+	res := services.NewPaymentService(pools, c).FindDuplicatesHandler(context.Background(), req)
+	if res.Code != cm.CodeSuccess {
+		fmt.Fprintln(os.Stderr, res.Remark)
+		return 1
+	}
+
+	for _, p := range res.Pairs {
+		fmt.Printf("%.3f  %-5s  %-5s  %s | %s  (%s)\n", p.Score, p.Customers[0].CustomerID, p.Customers[1].CustomerID,
+			p.Customers[0].CompanyName, p.Customers[1].CompanyName, strings.Join(p.Reasons, ", "))
+	}
+	return 0
+}
+
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	fs.Parse(args)
+
+	fmt.Printf("version:    %s\n", version)
+	if commit != "" {
+		fmt.Printf("commit:     %s\n", commit)
+	}
+	if buildDate != "" {
+		fmt.Printf("build date: %s\n", buildDate)
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		fmt.Printf("go:         %s\n", info.GoVersion)
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision", "vcs.time", "vcs.modified":
+				if commit == "" || s.Key != "vcs.revision" {
+					fmt.Printf("%-11s %s\n", s.Key+":", s.Value)
+				}
+			}
+		}
+	}
+
+	return 0
+}
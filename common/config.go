@@ -16,8 +16,13 @@
 package common
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
 
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
 	parser "Hanif_Aulia_Sabri-MyTrip/git/order/parser"
 
 	log "github.com/Sirupsen/logrus"
@@ -28,25 +33,255 @@ type Configuration struct {
 	ListenPort  string `yaml:"listenPort"`
 	RootURL     string `yaml:"rootUrl"`
 	RootURLBaru string `yaml:"rootUrlBaru"`
-	Connection  struct {
-		Host     string `yaml:"host"`
-		Port     int    `yaml:"port"`
-		Password string `yaml:"password"`
-		User     string `yaml:"user"`
-		Database string `yaml:"database"`
-	}
+
+	//file holding the named datasources, see database.LoadDatasources
 	DatabaseFile string `yaml:"databaseFile"`
+
+	//datasource used by every service area not listed in ServiceDatasources
+	DefaultDatasource string `yaml:"defaultDatasource"`
+
+	//service area (orders, customers, fastpay, trips) to datasource name, customers
+	//must share the datasource of orders
+	ServiceDatasources map[string]string `yaml:"serviceDatasources"`
+
+	//named datasources read from DatabaseFile
+	Datasources map[string]database.DbConnection `yaml:"-"`
+
+	//upstream trip provider called by TripsHandler
+	TripsURL string `yaml:"tripsUrl"`
+
+	//how often the config file is checked for changes, 0 reloads on SIGHUP only
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+
+	//how long in-flight requests may take to finish on SIGINT/SIGTERM,
+	//or on datasources replaced by a reload before they are closed
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+
+	//serve HTTPS instead of HTTP when certFile is set
+	TLS TLSConfig `yaml:"tls"`
+
+	//numbering and layout of the invoices rendered for orders
+	Invoice InvoiceConfig `yaml:"invoice"`
+
+	//credentials FastPay requests are signed with
+	FastPay FastPayConfig `yaml:"fastpay"`
+}
+
+//FastPayConfig points to the merchant credentials, kept in their own file like the datasources
+type FastPayConfig struct {
+	//file holding the merchants, merchant_id to FastPayMerchant
+	MerchantsFile string `yaml:"merchantsFile"`
+
+	//merchants read from MerchantsFile, then MYTRIP_FASTPAY_<MERCHANT_ID>_<KEY> and -fastpay overrides
+	Merchants map[string]FastPayMerchant `yaml:"-"`
+
+	//how far the signed timestamp of a request may be from the server clock, a request
+	//replayed later than that is rejected
+	MaxClockSkew time.Duration `yaml:"maxClockSkew"`
+}
+
+//FastPayMerchant is the user and password FastPay issued to one merchant
+type FastPayMerchant struct {
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+}
+
+//InvoiceConfig holds the HTML template of invoices and the texts shared by their HTML and PDF form.
+//The PDF form has the fixed layout of invoice.PDF, only the HTML form can be changed by a template.
+type InvoiceConfig struct {
+	//fmt format of the sequential invoice number, e.g. INV-%06d
+	NumberFormat string `yaml:"numberFormat"`
+
+	//html/template file executed with an invoice.Invoice, relative to the config file
+	HTMLTemplate string `yaml:"htmlTemplate"`
+
+	//lines printed at the top of every invoice, e.g. company name and address
+	Seller []string `yaml:"seller"`
+
+	Footer   string `yaml:"footer"`
+	Currency string `yaml:"currency"`
+}
+
+//TLSConfig describes the server certificate and the client certificates required per route prefix
+type TLSConfig struct {
+	CertFile   string       `yaml:"certFile"`
+	KeyFile    string       `yaml:"keyFile"`
+	MinVersion string       `yaml:"minVersion"`
+	ClientAuth []ClientAuth `yaml:"clientAuth"`
+}
+
+//ClientAuth requires a client certificate signed by CAFile on every path under Prefix (relative to rootUrl)
+type ClientAuth struct {
+	Prefix string `yaml:"prefix"`
+	CAFile string `yaml:"caFile"`
+}
+
+//Enabled tells whether the server listens with TLS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
 }
 
+//DefaultTripsURL is used when tripsUrl is not configured
+const DefaultTripsURL = "http://35.186.147.192/travel/GetTripsSample.php"
+
+//DefaultFastPayMaxClockSkew is used when fastpay.maxClockSkew is not configured
+const DefaultFastPayMaxClockSkew = 5 * time.Minute
+
+//Invoice defaults used when invoice.numberFormat or invoice.htmlTemplate are not configured,
+//the template relative to the config file
+const (
+	DefaultInvoiceNumberFormat = "INV-%06d"
+	DefaultInvoiceTemplate     = "templates/invoice.html"
+)
+
+//Config holds the configuration loaded at startup, use Current() for the live one
 var Config Configuration
 var logger *log.Entry
 
+//current holds the configuration in effect, swapped atomically on reload
+var current atomic.Value
+
+//LoadConfig builds a Configuration from, in increasing order of precedence:
+//
+//	1. the YAML file fn (skipped when fn is empty) and the datasources in its databaseFile
+//	2. MYTRIP_* environment variables, e.g. MYTRIP_DS_NORTHWIND_PASSWORD
+//	3. command line flags registered with RegisterFlags, e.g. -ds northwind.password=secret
+//
+//so a value given on the command line always wins over the environment,
+//which in turn wins over the files.
+func LoadConfig(fn string) (Configuration, error) {
+	var c Configuration
+
+	if fn != "" {
+		if err := parser.LoadYAML(&fn, &c); err != nil {
+			return c, fmt.Errorf("failed opening config file %s: %v", fn, err)
+		}
+	}
+
+	//the template ships beside the config file, an override names it relative to the working directory
+	c.Invoice.HTMLTemplate = relativeTo(fn, c.Invoice.HTMLTemplate)
+
+	if err := applyOverrides(&c); err != nil {
+		return c, fmt.Errorf("invalid configuration override %v", err)
+	}
+
+	c.Datasources = map[string]database.DbConnection{}
+	if c.DatabaseFile != "" {
+		ds, err := database.LoadDatasources(c.DatabaseFile)
+		if err != nil {
+			return c, fmt.Errorf("failed opening database file %s: %v", c.DatabaseFile, err)
+		}
+		c.Datasources = ds
+	}
+
+	if err := applyDatasourceOverrides(c.Datasources); err != nil {
+		return c, fmt.Errorf("invalid datasource override %v", err)
+	}
+
+	c.FastPay.Merchants = map[string]FastPayMerchant{}
+	if c.FastPay.MerchantsFile != "" {
+		if err := parser.LoadYAML(&c.FastPay.MerchantsFile, &c.FastPay.Merchants); err != nil {
+			return c, fmt.Errorf("failed opening fastpay merchants file %s: %v", c.FastPay.MerchantsFile, err)
+		}
+	}
+	if err := applyMerchantOverrides(c.FastPay.Merchants); err != nil {
+		return c, fmt.Errorf("invalid fastpay override %v", err)
+	}
+
+	if c.TripsURL == "" {
+		c.TripsURL = DefaultTripsURL
+	}
+	if c.FastPay.MaxClockSkew == 0 {
+		c.FastPay.MaxClockSkew = DefaultFastPayMaxClockSkew
+	}
+	if c.Invoice.NumberFormat == "" {
+		c.Invoice.NumberFormat = DefaultInvoiceNumberFormat
+	}
+	if c.Invoice.HTMLTemplate == "" {
+		c.Invoice.HTMLTemplate = relativeTo(fn, DefaultInvoiceTemplate)
+	}
+
+	if c.DefaultDatasource == "" && len(c.Datasources) == 1 {
+		for name := range c.Datasources {
+			c.DefaultDatasource = name
+		}
+	}
+
+	return c, nil
+}
+
+//relativeTo resolves path against the directory of the config file fn, the working directory
+//when there is none
+func relativeTo(fn string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(fn), path)
+}
+
+//LoadConfigFromFile loads the startup configuration into Config, see LoadConfig
 func LoadConfigFromFile(fn *string) {
-	if err := parser.LoadYAML(fn, &Config); err != nil {
-		log.Error("LoadConfigFromFile() - Failed opening config file")
+	var name string
+	if fn != nil {
+		name = *fn
+	}
+
+	c, err := LoadConfig(name)
+	if err != nil {
+		log.WithField("error", err).Error("LoadConfigFromFile() - Failed loading configuration")
+		os.Exit(1)
+	}
+
+	if err := c.Validate(); err != nil {
+		for _, ce := range err.(ConfigErrors) {
+			log.WithField("path", ce.Path).Error("LoadConfigFromFile() - Invalid configuration, ", ce.Problem)
+		}
 		os.Exit(1)
 	}
 
-	log.Info("Loaded configs: ", Config)
+	Config = c
+	SetCurrent(c)
 
+	log.Info("Loaded configs: ", c.Masked())
+
+}
+
+//Current returns the configuration in effect, including any reload applied since startup
+func Current() Configuration {
+	if c, ok := current.Load().(Configuration); ok {
+		return c
+	}
+	return Config
+}
+
+//SetCurrent atomically replaces the configuration returned by Current
+func SetCurrent(c Configuration) {
+	current.Store(c)
+}
+
+//Masked returns a copy of c that is safe to log
+func (c Configuration) Masked() Configuration {
+	ds := make(map[string]database.DbConnection, len(c.Datasources))
+	for name, d := range c.Datasources {
+		ds[name] = d.Masked()
+	}
+	c.Datasources = ds
+
+	merchants := make(map[string]FastPayMerchant, len(c.FastPay.Merchants))
+	for id, m := range c.FastPay.Merchants {
+		if m.Password != "" {
+			m.Password = "*****"
+		}
+		merchants[id] = m
+	}
+	c.FastPay.Merchants = merchants
+	return c
+}
+
+//DatasourceFor returns the name of the datasource serving a service area
+func (c Configuration) DatasourceFor(area string) string {
+	if name, ok := c.ServiceDatasources[area]; ok && name != "" {
+		return name
+	}
+	return c.DefaultDatasource
 }
@@ -1,5 +1,7 @@
 package common
 
+import "encoding/json"
+
 //Struct API
 // Order struct (Model) ...
 
@@ -11,20 +13,210 @@ type Message struct {
 	Result  *Result `json:"result,omitempty"`
 }
 
+//Orders totals are computed server side: Subtotal is the sum of the line amounts,
+//Total adds Freight to it. Version is bumped by every update and sent as the ETag.
 type Orders struct {
 	OrderID    string         `json:"orderID"`
 	CustomerID string         `json:"customerID"`
 	EmployeeID string         `json:"employeeID"`
 	OrderDate  string         `json:"orderDate"`
+	Status     string         `json:"status,omitempty"`
+	Version    int            `json:"version,omitempty"`
+	Freight    Money          `json:"freight"`
+	Subtotal   Money          `json:"subtotal"`
+	Total      Money          `json:"total"`
+	Shipping   *Shipping      `json:"shipping,omitempty"`
 	OrdersDet  []OrdersDetail `json:"ordersDetail"`
 }
 
+//Order statuses, the allowed moves between them are checked by the order service
+const (
+	OrderPendingPayment = "pending_payment"
+	OrderPaid           = "paid"
+	OrderShipped        = "shipped"
+	OrderCancelled      = "cancelled"
+)
+
+//StatusChange is one row of the status history of an order
+type StatusChange struct {
+	FromStatus string `json:"fromStatus,omitempty"`
+	ToStatus   string `json:"toStatus"`
+	Reason     string `json:"reason,omitempty"`
+	ChangedAt  string `json:"changedAt"`
+}
+
+type StatusHistory struct {
+	Code    int            `json:"code"`
+	Remark  string         `json:"remark"`
+	OrderID string         `json:"orderID"`
+	History []StatusChange `json:"history"`
+}
+
+//Shipping is where and how an order is delivered, empty fields are stored as NULL
+type Shipping struct {
+	ShipName       string `json:"shipName"`
+	ShipAddress    string `json:"shipAddress"`
+	ShipCity       string `json:"shipCity"`
+	ShipRegion     string `json:"shipRegion"`
+	ShipPostalCode string `json:"shipPostalCode"`
+	ShipCountry    string `json:"shipCountry"`
+	ShipVia        string `json:"shipVia"`
+	RequiredDate   string `json:"requiredDate"`
+	ShippedDate    string `json:"shippedDate,omitempty"`
+}
+
+//OrderUpdate changes an existing order. Version must be the one last read,
+//either from the body or from the If-Match header. Status and Reason are used
+//when moving the order to another status.
+type OrderUpdate struct {
+	OrderID   string         `json:"orderID"`
+	Version   int            `json:"version"`
+	OrdersDet []OrdersDetail `json:"ordersDetail,omitempty"`
+	Shipping  *Shipping      `json:"shipping,omitempty"`
+	Status    string         `json:"status,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+}
+
+//OrdersDetail Discount is a fraction (0.15 for 15%), Amount is
+//UnitPrice * Quantity * (1 - Discount) rounded to cents
 type OrdersDetail struct {
-	OrderID     string  `json:"orderID"`
-	ProductID   string  `json:"ProductID"`
-	ProductName string  `json:"ProductName"`
-	UnitPrice   float64 `json:"UnitPrice"`
-	Quantity    int     `json:"Quantity"`
+	OrderID     string `json:"orderID"`
+	ProductID   string `json:"ProductID"`
+	ProductName string `json:"ProductName"`
+	UnitPrice   Money  `json:"UnitPrice"`
+	Quantity    int    `json:"Quantity"`
+	Discount    Money  `json:"Discount"`
+	Amount      Money  `json:"Amount"`
+
+	//PriceGiven tells an explicit UnitPrice of 0 from an omitted one, which takes the product price
+	PriceGiven bool `json:"-"`
+}
+
+//UnmarshalJSON decodes d and sets PriceGiven when the UnitPrice key is present and not null
+func (d *OrdersDetail) UnmarshalJSON(b []byte) error {
+	type plain OrdersDetail
+	var v struct {
+		plain
+		UnitPrice *Money `json:"UnitPrice"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	*d = OrdersDetail(v.plain)
+	if v.UnitPrice != nil {
+		d.UnitPrice = *v.UnitPrice
+		d.PriceGiven = true
+	}
+	return nil
+}
+
+//OrderFilter selects, sorts and pages orders, empty fields do not filter
+type OrderFilter struct {
+	CustomerID string `json:"customerID,omitempty"`
+	EmployeeID string `json:"employeeID,omitempty"`
+	ProductID  string `json:"productID,omitempty"`
+	Status     string `json:"status,omitempty"`
+	DateFrom   string `json:"dateFrom,omitempty"`
+	DateTo     string `json:"dateTo,omitempty"`
+	Sort       string `json:"sort,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+	PageSize   int    `json:"pageSize,omitempty"`
+	Expand     bool   `json:"expand,omitempty"`
+}
+
+type OrderList struct {
+	Code   int      `json:"code"`
+	Remark string   `json:"remark"`
+	Orders []Orders `json:"orders"`
+	Page   *Page    `json:"page,omitempty"`
+}
+
+//Export formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+//OrderExport exports every order matching OrderFilter, one row per line, paging fields are ignored
+type OrderExport struct {
+	Format string `json:"format"`
+	OrderFilter
+}
+
+//CustomerFilter selects customers, empty fields do not filter
+type CustomerFilter struct {
+	Country string `json:"country,omitempty"`
+	City    string `json:"city,omitempty"`
+}
+
+//CustomerSearch finds customers whose CompanyName, ContactName, City, Country or Phone match
+//every word of Q, by word prefix (Match "prefix", the default) or anywhere (Match "contains").
+//Results are ranked by how well they match.
+type CustomerSearch struct {
+	Q        string `json:"q,omitempty"`
+	Match    string `json:"match,omitempty"`
+	Offset   int    `json:"offset,omitempty"`
+	PageSize int    `json:"pageSize,omitempty"`
+	CustomerFilter
+}
+
+//CustomerMatch is a search result, a higher Score is a better match
+type CustomerMatch struct {
+	Customers
+	Score int `json:"score"`
+}
+
+type CustomerList struct {
+	Code      int             `json:"code"`
+	Remark    string          `json:"remark"`
+	Customers []CustomerMatch `json:"customers"`
+	Page      *Page           `json:"page,omitempty"`
+}
+
+type CustomerExport struct {
+	Format string `json:"format"`
+	CustomerFilter
+}
+
+//Export is a file streamed to the client instead of a JSON body. Rows runs the query
+//and calls write once per row as it is read, so the result is never held in memory.
+//Code and Remark are answered as JSON when the request is rejected.
+type Export struct {
+	Code   int                                             `json:"code"`
+	Remark string                                          `json:"remark"`
+	Name   string                                          `json:"-"`
+	Format string                                          `json:"-"`
+	Header []string                                        `json:"-"`
+	Rows   func(write func(row []interface{}) error) error `json:"-"`
+}
+
+//Invoice formats
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+type InvoiceRequest struct {
+	OrderID string `json:"orderID"`
+	Format  string `json:"format"`
+}
+
+//Document is a rendered file answered as is, Code and Remark are answered as JSON
+//when it could not be rendered
+type Document struct {
+	Code        int    `json:"code"`
+	Remark      string `json:"remark"`
+	Name        string `json:"-"`
+	ContentType string `json:"-"`
+	Body        []byte `json:"-"`
+}
+
+type Page struct {
+	Offset     int  `json:"offset"`
+	PageSize   int  `json:"pageSize"`
+	NextOffset int  `json:"nextOffset,omitempty"`
+	HasMore    bool `json:"hasMore"`
 }
 
 type Result struct {
@@ -44,15 +236,123 @@ type Customers struct {
 	PostalCode   string `json:"PostalCode"`
 }
 
+type CustomerResult struct {
+	Code     int          `json:"code"`
+	Remark   string       `json:"remark"`
+	Customer *Customers   `json:"customer,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+//FieldError is one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+//CustomerOrders is a customer with one page of their orders, lines and totals included
+type CustomerOrders struct {
+	Code     int        `json:"code"`
+	Remark   string     `json:"remark"`
+	Customer *Customers `json:"customer,omitempty"`
+	Orders   []Orders   `json:"orders"`
+	Page     *Page      `json:"page,omitempty"`
+}
+
+//DuplicateSearch selects the customers compared with each other for likely duplicates
+type DuplicateSearch struct {
+	MinScore float64 `json:"minScore"`
+	CustomerFilter
+}
+
+//DuplicatePair is two customers that are likely the same, Reasons names the fields that matched
+type DuplicatePair struct {
+	Customers [2]Customers `json:"customers"`
+	Score     float64      `json:"score"`
+	Reasons   []string     `json:"reasons"`
+}
+
+type DuplicateList struct {
+	Code   int             `json:"code"`
+	Remark string          `json:"remark"`
+	Pairs  []DuplicatePair `json:"pairs"`
+}
+
+//CustomerMerge folds the customer DuplicateID into SurvivorID
+type CustomerMerge struct {
+	SurvivorID  string `json:"-"`
+	DuplicateID string `json:"duplicateID"`
+	Reason      string `json:"reason"`
+}
+
+//MergeRecord is the audit of one merge, Duplicate is the customer as it was before its removal
+type MergeRecord struct {
+	MergeID     int64     `json:"mergeID"`
+	SurvivorID  string    `json:"survivorID"`
+	DuplicateID string    `json:"duplicateID"`
+	OrdersMoved int64     `json:"ordersMoved"`
+	Reason      string    `json:"reason,omitempty"`
+	MergedAt    string    `json:"mergedAt"`
+	Duplicate   Customers `json:"duplicate"`
+}
+
+type MergeResult struct {
+	Code     int          `json:"code"`
+	Remark   string       `json:"remark"`
+	Customer *Customers   `json:"customer,omitempty"`
+	Merge    *MergeRecord `json:"merge,omitempty"`
+}
+
+//CustomerPatch changes only the fields present in the request body, CustomerID comes from the path
+type CustomerPatch struct {
+	CustomerID   string  `json:"-"`
+	CompanyName  *string `json:"CompanyName"`
+	ContactName  *string `json:"ContactName"`
+	ContactTitle *string `json:"ContactTitle"`
+	Address      *string `json:"Address"`
+	City         *string `json:"City"`
+	Country      *string `json:"Country"`
+	Phone        *string `json:"Phone"`
+	PostalCode   *string `json:"PostalCode"`
+}
+
+//Health is returned by the health and readiness endpoints
+type Health struct {
+	Status string             `json:"status"`
+	Checks []DependencyStatus `json:"checks,omitempty"`
+}
+
+type DependencyStatus struct {
+	Name      string  `json:"name"`
+	Type      string  `json:"type"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
 //End Struct API
 
+//FastPayRequest Timestamp is when the request was signed, in unix seconds
 type FastPayRequest struct {
 	Merchant   string `json:"merchant"`
 	MerchantID string `json:"merchant_id"`
 	Request    string `json:"request"`
+	Timestamp  string `json:"timestamp"`
 	Signature  string `json:"signature"`
 }
 
+//FastPay response codes. An unknown merchant_id gets FastPayInvalidSignature as well,
+//so merchant ids cannot be probed
+const (
+	FastPaySuccess          = "00"
+	FastPayInvalidRequest   = "01"
+	FastPayInvalidSignature = "02"
+)
+
 type FastPayResponse struct {
 	Response       string           `json:"response"`
 	Merchant       string           `json:"merchant"`
@@ -0,0 +1,18 @@
+package common
+
+import "github.com/shopspring/decimal"
+
+//Money is an exact decimal amount written to JSON as a number, as existing clients read prices and totals
+type Money struct {
+	decimal.Decimal
+}
+
+//NewMoney wraps d
+func NewMoney(d decimal.Decimal) Money {
+	return Money{Decimal: d}
+}
+
+//MarshalJSON writes m without quotes, other decimals keep the quoted form of the library
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
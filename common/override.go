@@ -0,0 +1,275 @@
+package common
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+)
+
+//override binds one Configuration field to its environment variable and command line flag
+type override struct {
+	env   string
+	flag  string
+	usage string
+	apply func(c *Configuration, v string) error
+}
+
+var overrides = []override{
+	{"MYTRIP_LISTEN_PORT", "listen-port", "address the server listens on, e.g. :9000",
+		func(c *Configuration, v string) error { c.ListenPort = v; return nil }},
+	{"MYTRIP_ROOT_URL", "root-url", "prefix of every registered path",
+		func(c *Configuration, v string) error { c.RootURL = v; return nil }},
+	{"MYTRIP_ROOT_URL_BARU", "root-url-baru", "alternative root url",
+		func(c *Configuration, v string) error { c.RootURLBaru = v; return nil }},
+	{"MYTRIP_DATABASE_FILE", "database-file", "database definition file",
+		func(c *Configuration, v string) error { c.DatabaseFile = v; return nil }},
+	{"MYTRIP_DEFAULT_DATASOURCE", "default-datasource", "datasource used by service areas without their own",
+		func(c *Configuration, v string) error { c.DefaultDatasource = v; return nil }},
+	{"MYTRIP_TRIPS_URL", "trips-url", "upstream trip provider",
+		func(c *Configuration, v string) error { c.TripsURL = v; return nil }},
+	{"MYTRIP_RELOAD_INTERVAL", "reload-interval", "how often the config file is checked for changes, 0 for SIGHUP only",
+		func(c *Configuration, v string) error { return setDuration(&c.ReloadInterval, v) }},
+	{"MYTRIP_SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long in-flight requests may take to finish on shutdown",
+		func(c *Configuration, v string) error { return setDuration(&c.ShutdownTimeout, v) }},
+	{"MYTRIP_TLS_CERT_FILE", "tls-cert-file", "server certificate, enables HTTPS",
+		func(c *Configuration, v string) error { c.TLS.CertFile = v; return nil }},
+	{"MYTRIP_TLS_KEY_FILE", "tls-key-file", "server certificate private key",
+		func(c *Configuration, v string) error { c.TLS.KeyFile = v; return nil }},
+	{"MYTRIP_TLS_MIN_VERSION", "tls-min-version", "minimum TLS version: 1.0, 1.1, 1.2 or 1.3",
+		func(c *Configuration, v string) error { c.TLS.MinVersion = v; return nil }},
+	{"MYTRIP_INVOICE_HTML_TEMPLATE", "invoice-html-template", "html/template file invoices are rendered with",
+		func(c *Configuration, v string) error { c.Invoice.HTMLTemplate = v; return nil }},
+	{"MYTRIP_FASTPAY_MERCHANTS_FILE", "fastpay-merchants-file", "file holding the FastPay merchant credentials",
+		func(c *Configuration, v string) error { c.FastPay.MerchantsFile = v; return nil }},
+}
+
+//overrideValue remembers whether a flag was given on the command line
+type overrideValue struct {
+	value string
+	set   bool
+}
+
+func (o *overrideValue) String() string { return o.value }
+
+func (o *overrideValue) Set(v string) error {
+	o.value = v
+	o.set = true
+	return nil
+}
+
+var flagValues = map[string]*overrideValue{}
+
+//datasourceField binds one DbConnection field to MYTRIP_DS_<NAME>_<KEY> and -ds <name>.<key>=
+type datasourceField struct {
+	key   string
+	apply func(d *database.DbConnection, v string) error
+}
+
+var datasourceFields = []datasourceField{
+	{"type", func(d *database.DbConnection, v string) error { d.Type = v; return nil }},
+	{"url", func(d *database.DbConnection, v string) error { d.URL = v; return nil }},
+	{"username", func(d *database.DbConnection, v string) error { d.Username = v; return nil }},
+	{"password", func(d *database.DbConnection, v string) error { d.Password = v; return nil }},
+	{"host", func(d *database.DbConnection, v string) error { d.Host = v; return nil }},
+	{"schema", func(d *database.DbConnection, v string) error { d.Schema = v; return nil }},
+	{"max_open_conns", func(d *database.DbConnection, v string) error { return setInt(&d.MaxOpenConns, v) }},
+	{"max_idle_conns", func(d *database.DbConnection, v string) error { return setInt(&d.MaxIdleConns, v) }},
+	{"conn_max_lifetime", func(d *database.DbConnection, v string) error { return setDuration(&d.ConnMaxLifetime, v) }},
+}
+
+//datasourceFlag collects every -ds name.key=value given on the command line
+type datasourceFlag []string
+
+func (d *datasourceFlag) String() string { return strings.Join(*d, ",") }
+
+func (d *datasourceFlag) Set(v string) error {
+	if !strings.Contains(v, "=") || !strings.Contains(v[:strings.Index(v, "=")], ".") {
+		return fmt.Errorf("want name.key=value, got %q", v)
+	}
+	*d = append(*d, v)
+	return nil
+}
+
+var datasourceFlags datasourceFlag
+
+//merchantField binds one FastPayMerchant field to MYTRIP_FASTPAY_<ID>_<KEY> and -fastpay <id>.<key>=,
+//so the password FastPay issued need not be written to the merchants file
+type merchantField struct {
+	key   string
+	apply func(m *FastPayMerchant, v string)
+}
+
+var merchantFields = []merchantField{
+	{"user", func(m *FastPayMerchant, v string) { m.User = v }},
+	{"password", func(m *FastPayMerchant, v string) { m.Password = v }},
+}
+
+//merchantFlags collects every -fastpay id.key=value given on the command line, checked like -ds
+var merchantFlags datasourceFlag
+
+//RegisterFlags adds one command line flag per overridable field to fs.
+//It must be called before fs.Parse.
+func RegisterFlags(fs *flag.FlagSet) {
+	for _, o := range overrides {
+		v := &overrideValue{}
+		flagValues[o.flag] = v
+		fs.Var(v, o.flag, fmt.Sprintf("%s (env %s)", o.usage, o.env))
+	}
+
+	keys := make([]string, len(datasourceFields))
+	for i, f := range datasourceFields {
+		keys[i] = f.key
+	}
+	fs.Var(&datasourceFlags, "ds", fmt.Sprintf("datasource override name.key=value, repeatable, key is one of %s (env MYTRIP_DS_<NAME>_<KEY>)",
+		strings.Join(keys, ", ")))
+	fs.Var(&merchantFlags, "fastpay", "FastPay merchant override merchant_id.key=value, repeatable, key is user or password (env MYTRIP_FASTPAY_<MERCHANT_ID>_<KEY>)")
+}
+
+//applyOverrides layers environment variables and then command line flags on top of c
+func applyOverrides(c *Configuration) error {
+	for _, o := range overrides {
+		if v, ok := os.LookupEnv(o.env); ok {
+			if err := o.apply(c, v); err != nil {
+				return fmt.Errorf("%s: %v", o.env, err)
+			}
+		}
+	}
+
+	for _, o := range overrides {
+		if v, ok := flagValues[o.flag]; ok && v.set {
+			if err := o.apply(c, v.value); err != nil {
+				return fmt.Errorf("-%s: %v", o.flag, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+//applyDatasourceOverrides layers MYTRIP_DS_* variables and then -ds flags on top of ds,
+//a datasource missing from the database file is created by its first override
+func applyDatasourceOverrides(ds map[string]database.DbConnection) error {
+	for _, kv := range os.Environ() {
+		eq := strings.Index(kv, "=")
+		if eq < 0 || !strings.HasPrefix(kv[:eq], "MYTRIP_DS_") {
+			continue
+		}
+		env, v := kv[:eq], kv[eq+1:]
+		rest := strings.TrimPrefix(env, "MYTRIP_DS_")
+
+		for _, f := range datasourceFields {
+			suffix := "_" + strings.ToUpper(f.key)
+			if len(rest) > len(suffix) && strings.HasSuffix(rest, suffix) {
+				name := strings.ToLower(strings.TrimSuffix(rest, suffix))
+				if err := applyDatasourceField(ds, name, f, v); err != nil {
+					return fmt.Errorf("%s: %v", env, err)
+				}
+				break
+			}
+		}
+	}
+
+	for _, kv := range datasourceFlags {
+		eq := strings.Index(kv, "=")
+		target, v := kv[:eq], kv[eq+1:]
+		dot := strings.LastIndex(target, ".")
+		name, key := target[:dot], strings.ToLower(target[dot+1:])
+
+		found := false
+		for _, f := range datasourceFields {
+			if f.key == key {
+				if err := applyDatasourceField(ds, name, f, v); err != nil {
+					return fmt.Errorf("-ds %s: %v", target, err)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("-ds %s: unknown key %q", target, key)
+		}
+	}
+
+	return nil
+}
+
+//applyMerchantOverrides layers MYTRIP_FASTPAY_<ID>_USER and _PASSWORD variables and then
+//-fastpay flags on top of merchants, a merchant missing from the merchants file is created
+//by its first override. merchant ids are taken as given, not case folded.
+func applyMerchantOverrides(merchants map[string]FastPayMerchant) error {
+	for _, kv := range os.Environ() {
+		eq := strings.Index(kv, "=")
+		if eq < 0 || !strings.HasPrefix(kv[:eq], "MYTRIP_FASTPAY_") {
+			continue
+		}
+		env, v := kv[:eq], kv[eq+1:]
+		rest := strings.TrimPrefix(env, "MYTRIP_FASTPAY_")
+
+		for _, f := range merchantFields {
+			suffix := "_" + strings.ToUpper(f.key)
+			if len(rest) > len(suffix) && strings.HasSuffix(rest, suffix) {
+				applyMerchantField(merchants, strings.TrimSuffix(rest, suffix), f, v)
+				break
+			}
+		}
+	}
+
+	for _, kv := range merchantFlags {
+		eq := strings.Index(kv, "=")
+		target, v := kv[:eq], kv[eq+1:]
+		dot := strings.LastIndex(target, ".")
+		id, key := target[:dot], strings.ToLower(target[dot+1:])
+
+		found := false
+		for _, f := range merchantFields {
+			if f.key == key {
+				applyMerchantField(merchants, id, f, v)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("-fastpay %s: unknown key %q", target, key)
+		}
+	}
+
+	return nil
+}
+
+func applyMerchantField(merchants map[string]FastPayMerchant, id string, f merchantField, v string) {
+	m := merchants[id]
+	f.apply(&m, v)
+	merchants[id] = m
+}
+
+func applyDatasourceField(ds map[string]database.DbConnection, name string, f datasourceField, v string) error {
+	d := ds[name]
+	if err := f.apply(&d, v); err != nil {
+		return err
+	}
+	ds[name] = d
+	return nil
+}
+
+func setInt(dst *int, v string) error {
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return err
+	}
+	*dst = i
+	return nil
+}
+
+func setDuration(dst *time.Duration, v string) error {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return err
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,58 @@
+package common
+
+//Result codes answered in the code field, the transport maps each one to an HTTP status
+const (
+	CodeSuccess          = 100
+	CodeInvalid          = 99
+	CodeForbidden        = 98
+	CodePathNotFound     = 97
+	CodeMethodNotAllowed = 96
+	CodeConflict         = 95
+	CodeNotFound         = 94
+	CodeIllegalStatus    = 93
+	CodeInternal         = 90
+)
+
+//ResultCode is the code of m, falling back to Result for messages that only carry that
+func (m Message) ResultCode() int {
+	if m.Code == 0 && m.Result != nil {
+		return m.Result.Code
+	}
+	return m.Code
+}
+
+func (l CustomerList) ResultCode() int {
+	return l.Code
+}
+
+func (c CustomerOrders) ResultCode() int {
+	return c.Code
+}
+
+func (d DuplicateList) ResultCode() int {
+	return d.Code
+}
+
+func (m MergeResult) ResultCode() int {
+	return m.Code
+}
+
+func (c CustomerResult) ResultCode() int {
+	return c.Code
+}
+
+func (d Document) ResultCode() int {
+	return d.Code
+}
+
+func (h StatusHistory) ResultCode() int {
+	return h.Code
+}
+
+func (l OrderList) ResultCode() int {
+	return l.Code
+}
+
+func (e Export) ResultCode() int {
+	return e.Code
+}
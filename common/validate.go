@@ -0,0 +1,247 @@
+package common
+
+import (
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+)
+
+//ConfigError describes one invalid configuration value by its YAML path
+type ConfigError struct {
+	Path    string
+	Problem string
+}
+
+func (e ConfigError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Problem)
+}
+
+//ConfigErrors collects every problem found by Validate
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, ce := range e {
+		msgs[i] = ce.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *ConfigErrors) add(path string, problem string, a ...interface{}) {
+	*e = append(*e, ConfigError{Path: path, Problem: fmt.Sprintf(problem, a...)})
+}
+
+//Validate checks c and returns every problem found as ConfigErrors, or nil when c is usable
+func (c Configuration) Validate() error {
+	var errs ConfigErrors
+
+	validateListenPort(&errs, "listenPort", c.ListenPort)
+	validateRootURL(&errs, "rootUrl", c.RootURL)
+	validateRootURL(&errs, "rootUrlBaru", c.RootURLBaru)
+
+	if c.DatabaseFile == "" && len(c.Datasources) == 0 {
+		errs.add("databaseFile", "is required, it holds the named datasources")
+	}
+
+	names := make([]string, 0, len(c.Datasources))
+	for name := range c.Datasources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	file := c.DatabaseFile
+	if file == "" {
+		file = "datasources"
+	}
+	for _, name := range names {
+		validateDatasource(&errs, fmt.Sprintf("%s:%s", file, name), c.Datasources[name])
+	}
+
+	if c.DefaultDatasource == "" {
+		errs.add("defaultDatasource", "is required when databaseFile holds more than one datasource")
+	} else if _, ok := c.Datasources[c.DefaultDatasource]; !ok {
+		errs.add("defaultDatasource", "refers to unknown datasource %q", c.DefaultDatasource)
+	}
+
+	for area, name := range c.ServiceDatasources {
+		if _, ok := c.Datasources[name]; !ok {
+			errs.add("serviceDatasources."+area, "refers to unknown datasource %q", name)
+		}
+	}
+	//merging and deleting customers move and count their orders in the same transaction
+	if orders, customers := c.DatasourceFor("orders"), c.DatasourceFor("customers"); orders != customers {
+		errs.add("serviceDatasources.customers", "must be the datasource of orders (%q), got %q", orders, customers)
+	}
+
+	if c.ReloadInterval < 0 {
+		errs.add("reloadInterval", "must not be negative")
+	}
+	if c.ShutdownTimeout < 0 {
+		errs.add("shutdownTimeout", "must not be negative")
+	}
+
+	if u, err := url.Parse(c.TripsURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add("tripsUrl", "must be an absolute http(s) URL, got %q", c.TripsURL)
+	}
+
+	validateTLS(&errs, c.TLS)
+	validateInvoice(&errs, c.Invoice)
+	validateFastPay(&errs, c.FastPay)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+//TLSVersions maps the accepted tls.minVersion values to crypto/tls constants
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func validateTLS(errs *ConfigErrors, t TLSConfig) {
+	if !t.Enabled() {
+		if t.KeyFile != "" {
+			errs.add("tls.certFile", "is required when tls.keyFile is set")
+		}
+		if len(t.ClientAuth) > 0 {
+			errs.add("tls.clientAuth", "needs tls.certFile and tls.keyFile, client certificates only work over TLS")
+		}
+		return
+	}
+
+	if t.KeyFile == "" {
+		errs.add("tls.keyFile", "is required when tls.certFile is set")
+	}
+	validateReadable(errs, "tls.certFile", t.CertFile)
+	validateReadable(errs, "tls.keyFile", t.KeyFile)
+
+	if _, ok := TLSVersions[t.MinVersion]; t.MinVersion != "" && !ok {
+		errs.add("tls.minVersion", "must be one of 1.0, 1.1, 1.2 or 1.3, got %q", t.MinVersion)
+	}
+
+	for i, ca := range t.ClientAuth {
+		path := fmt.Sprintf("tls.clientAuth[%d]", i)
+		if ca.Prefix == "" || ca.Prefix == "/" {
+			errs.add(path+".prefix", "is required, e.g. /fastpay")
+		} else {
+			validateRootURL(errs, path+".prefix", ca.Prefix)
+		}
+		if ca.CAFile == "" {
+			errs.add(path+".caFile", "is required")
+		} else {
+			validateReadable(errs, path+".caFile", ca.CAFile)
+		}
+	}
+}
+
+func validateInvoice(errs *ConfigErrors, inv InvoiceConfig) {
+	if n := fmt.Sprintf(inv.NumberFormat, 1); strings.Contains(n, "%!") || n == inv.NumberFormat {
+		errs.add("invoice.numberFormat", "must hold a single integer verb such as %%06d, got %q", inv.NumberFormat)
+	}
+
+	if _, err := template.ParseFiles(inv.HTMLTemplate); err != nil {
+		errs.add("invoice.htmlTemplate", "cannot be used: %v", err)
+	}
+}
+
+func validateFastPay(errs *ConfigErrors, f FastPayConfig) {
+	if f.MaxClockSkew < 0 {
+		errs.add("fastpay.maxClockSkew", "must not be negative")
+	}
+
+	ids := make([]string, 0, len(f.Merchants))
+	for id := range f.Merchants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	file := f.MerchantsFile
+	if file == "" {
+		file = "fastpay"
+	}
+	for _, id := range ids {
+		m := f.Merchants[id]
+		if m.User == "" {
+			errs.add(fmt.Sprintf("%s:%s.user", file, id), "is required")
+		}
+		if m.Password == "" {
+			errs.add(fmt.Sprintf("%s:%s.password", file, id), "is required, or set MYTRIP_FASTPAY_%s_PASSWORD", id)
+		}
+	}
+}
+
+func validateReadable(errs *ConfigErrors, path string, fn string) {
+	if fn == "" {
+		return
+	}
+	if _, err := os.Stat(fn); err != nil {
+		errs.add(path, "cannot be read: %v", err)
+	}
+}
+
+func validateDatasource(errs *ConfigErrors, path string, d database.DbConnection) {
+	if d.Type == "" {
+		errs.add(path+".Type", "is required, e.g. mysql")
+	}
+	if !d.HasDSN() {
+		errs.add(path+".URL", "is required unless Username, Password, Host and Schema are all set")
+	}
+	if d.MaxOpenConns < 0 {
+		errs.add(path+".MaxOpenConns", "must not be negative")
+	}
+	if d.MaxIdleConns < 0 {
+		errs.add(path+".MaxIdleConns", "must not be negative")
+	}
+	if d.MaxOpenConns > 0 && d.MaxIdleConns > d.MaxOpenConns {
+		errs.add(path+".MaxIdleConns", "must not exceed MaxOpenConns (%d)", d.MaxOpenConns)
+	}
+	if d.ConnMaxLifetime < 0 {
+		errs.add(path+".ConnMaxLifetime", "must not be negative")
+	}
+}
+
+//validateListenPort accepts host:port or :port
+func validateListenPort(errs *ConfigErrors, path string, v string) {
+	if v == "" {
+		errs.add(path, "is required, e.g. \":9000\"")
+		return
+	}
+
+	_, port, err := net.SplitHostPort(v)
+	if err != nil {
+		errs.add(path, "must look like \":9000\" or \"host:9000\", got %q", v)
+		return
+	}
+
+	if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+		errs.add(path, "port must be a number between 0 and 65535, got %q", port)
+	}
+}
+
+//validateRootURL accepts an empty prefix or one like /getOrder
+func validateRootURL(errs *ConfigErrors, path string, v string) {
+	if v == "" {
+		return
+	}
+
+	switch {
+	case !strings.HasPrefix(v, "/"):
+		errs.add(path, "must start with \"/\", got %q", v)
+	case len(v) > 1 && strings.HasSuffix(v, "/"):
+		errs.add(path, "must not end with \"/\", got %q", v)
+	case strings.ContainsAny(v, "?# \t"):
+		errs.add(path, "must be a plain path without query, fragment or spaces, got %q", v)
+	}
+}
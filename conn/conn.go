@@ -34,10 +34,22 @@ type DbConnection struct {
 	Host     string            `yaml:"Host"`
 	Schema   string            `yaml:"Schema"`
 	SQL      map[string]string `yaml:"SQLCommand"`
-	Db       *sql.DB
-	Tx       *sql.Tx
+
+	//pool settings, zero values fall back to the defaults below
+	MaxOpenConns    int           `yaml:"MaxOpenConns"`
+	MaxIdleConns    int           `yaml:"MaxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"ConnMaxLifetime"`
+
+	Db *sql.DB `yaml:"-"`
+	Tx *sql.Tx `yaml:"-"`
 }
 
+const (
+	defaultMaxOpenConns    = 100
+	defaultMaxIdleConns    = 20
+	defaultConnMaxLifetime = 30 * time.Minute
+)
+
 //var c.Db *sql.DB
 
 func New(fn string) (*DbConnection, error) {
@@ -61,7 +73,10 @@ func New(fn string) (*DbConnection, error) {
 	return &c, nil
 }
 
-func (c DbConnection) Begin() error {
+//Begin starts a transaction used by QueryTx/ExecTx until Commit or Rollback.
+//c is usually a per request copy of a shared connection, so concurrent
+//transactions on the same pool do not overwrite each other's Tx.
+func (c *DbConnection) Begin() error {
 	var e error
 	c.Tx, e = c.Db.Begin()
 	if e != nil {
@@ -70,6 +85,12 @@ func (c DbConnection) Begin() error {
 	return nil
 }
 
+//WithTx returns a copy of c sharing its pool, ready for its own Begin
+func (c DbConnection) WithTx() *DbConnection {
+	c.Tx = nil
+	return &c
+}
+
 func (c DbConnection) Commit() error {
 	return c.Tx.Commit()
 }
@@ -96,10 +117,20 @@ func (c DbConnection) Open() (*sql.DB, error) {
 		return nil, err
 	}
 
-	dbConn.SetMaxOpenConns(100)
+	maxOpen, maxIdle, lifetime := c.MaxOpenConns, c.MaxIdleConns, c.ConnMaxLifetime
+	if maxOpen <= 0 {
+		maxOpen = defaultMaxOpenConns
+	}
+	if maxIdle <= 0 {
+		maxIdle = defaultMaxIdleConns
+	}
+	if lifetime <= 0 {
+		lifetime = defaultConnMaxLifetime
+	}
 
-	dbConn.SetMaxIdleConns(20)
-	dbConn.SetConnMaxLifetime(30 * time.Minute)
+	dbConn.SetMaxOpenConns(maxOpen)
+	dbConn.SetMaxIdleConns(maxIdle)
+	dbConn.SetConnMaxLifetime(lifetime)
 
 	err = dbConn.Ping()
 	if err != nil {
@@ -318,6 +349,33 @@ func (c DbConnection) InsertGetLastId(sqlStringName string, args ...interface{})
 	return rows, nil
 }
 
+func (c DbConnection) InsertGetLastIdTx(sqlStringName string, args ...interface{}) (int64, error) {
+	// if no transaction, return
+	//
+	if c.Tx == nil {
+		return 0, fmt.Errorf("Please Begin() transaction first")
+	}
+
+	var strSQL string
+	var found bool
+
+	if strSQL, found = c.SQL[sqlStringName]; !found {
+		strSQL = sqlStringName
+	}
+
+	// Execute the query
+	res, err := c.Tx.Exec(strSQL, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
 func (c DbConnection) Queryf(sql string, a ...interface{}) (*sql.Rows, error) {
 	return c.Query(fmt.Sprintf(sql, a...))
 }
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"strings"
+
+	"Hanif_Aulia_Sabri-MyTrip/git/order/parser"
+)
+
+//LoadDatasources reads a file of named datasources, each one a DbConnection:
+//
+//	northwind:
+//	  Type: mysql
+//	  URL: user:password@tcp(localhost:3306)/northwind
+//	  MaxOpenConns: 100
+//	  SQLCommand:
+//	    GetOrder: select ...
+func LoadDatasources(fn string) (map[string]DbConnection, error) {
+	ds := map[string]DbConnection{}
+	if err := parser.LoadYAML(&fn, &ds); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+//HasDSN tells whether either URL or all of its parts are set, see Open
+func (c DbConnection) HasDSN() bool {
+	if c.URL != "" {
+		return true
+	}
+	return c.Username != "" && c.Password != "" && c.Host != "" && c.Schema != ""
+}
+
+//Masked returns a copy of c with the password hidden, both standalone and inside URL
+func (c DbConnection) Masked() DbConnection {
+	if c.Password != "" {
+		c.Password = "*****"
+	}
+
+	if at := strings.LastIndex(c.URL, "@"); at > 0 {
+		if colon := strings.Index(c.URL[:at], ":"); colon >= 0 {
+			c.URL = c.URL[:colon+1] + "*****" + c.URL[at:]
+		}
+	}
+
+	c.Db = nil
+	c.Tx = nil
+	return c
+}
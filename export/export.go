@@ -0,0 +1,171 @@
+//Package export writes a cm.Export as CSV or XLSX while its rows are read
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+)
+
+//Writer receives the rows of an export one at a time, Close completes the file
+type Writer interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+//ContentType is the media type of format
+func ContentType(format string) string {
+	if format == cm.FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+//Supported tells whether format can be written
+func Supported(format string) bool {
+	return format == cm.FormatCSV || format == cm.FormatXLSX
+}
+
+//NewWriter returns the writer of format on w, sheet names the XLSX worksheet
+func NewWriter(format string, sheet string, w io.Writer) (Writer, error) {
+	switch format {
+	case cm.FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case cm.FormatXLSX:
+		return newXLSXWriter(sheet, w)
+	}
+	return nil, fmt.Errorf("unsupported export format %q", format)
+}
+
+//Write streams e to w in e.Format, starting with its header
+func Write(e cm.Export, w io.Writer) error {
+	ew, err := NewWriter(e.Format, e.Name, w)
+	if err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(e.Header))
+	for i, h := range e.Header {
+		header[i] = h
+	}
+	if err := ew.Write(header); err != nil {
+		return err
+	}
+
+	if err := e.Rows(ew.Write); err != nil {
+		return err
+	}
+	return ew.Close()
+}
+
+//formulaPrefixes start a formula when a spreadsheet opens the cell, so text starting
+//with one of them is written behind a quote to stay text
+const formulaPrefixes = "=+-@\t\r"
+
+//plainNumber is a signed number or phone number, at worst arithmetic to a spreadsheet and
+//never a call, so "+49 30 0074321" and "-1.50" are written as they are
+var plainNumber = regexp.MustCompile(`^[+-][0-9][0-9 ().,/-]*$`)
+
+//escapeFormula keeps a text cell from being evaluated as a formula
+func escapeFormula(s string) string {
+	if s == "" || strings.IndexByte(formulaPrefixes, s[0]) < 0 {
+		return s
+	}
+	if (s[0] == '+' || s[0] == '-') && plainNumber.MatchString(s) {
+		return s
+	}
+	return "'" + s
+}
+
+type csvWriter struct {
+	w   *csv.Writer
+	buf []string
+}
+
+func (c *csvWriter) Write(row []interface{}) error {
+	c.buf = c.buf[:0]
+	for _, v := range row {
+		if text, ok := v.(string); ok {
+			c.buf = append(c.buf, escapeFormula(text))
+			continue
+		}
+		c.buf = append(c.buf, fmt.Sprint(v))
+	}
+	return c.w.Write(c.buf)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+//xlsxWriter goes through the excelize stream writer, which spills rows to a
+//temporary file past a few MB instead of keeping the sheet in memory
+type xlsxWriter struct {
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	out    io.Writer
+	row    int
+}
+
+func newXLSXWriter(sheet string, w io.Writer) (*xlsxWriter, error) {
+	f := excelize.NewFile()
+	if sheet != "" {
+		if err := f.SetSheetName("Sheet1", sheet); err != nil {
+			f.Close()
+			return nil, err
+		}
+	} else {
+		sheet = "Sheet1"
+	}
+
+	stream, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &xlsxWriter{file: f, stream: stream, out: w}, nil
+}
+
+func (x *xlsxWriter) Write(row []interface{}) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		//money stays numeric so it can be summed in the sheet
+		switch value := v.(type) {
+		case cm.Money:
+			v = value.InexactFloat64()
+		case decimal.Decimal:
+			v = value.InexactFloat64()
+		case string:
+			v = escapeFormula(value)
+		}
+		values[i] = v
+	}
+	return x.stream.SetRow(cell, values)
+}
+
+//Close writes the workbook to out, the error of closing the file is returned as well since
+//it removes the temporary files of the stream writer
+func (x *xlsxWriter) Close() error {
+	err := x.stream.Flush()
+	if err == nil {
+		err = x.file.Write(x.out)
+	}
+	if cerr := x.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
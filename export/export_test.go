@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEscapeFormula(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"Alfreds Futterkiste", "Alfreds Futterkiste"},
+		{"=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"+1 555 0100", "+1 555 0100"},
+		{"+49300074321", "+49300074321"},
+		{"+1 (555) 010-0100", "+1 (555) 010-0100"},
+		{"-1.50", "-1.50"},
+		{"-2+3", "'-2+3"},
+		{"+A1", "'+A1"},
+		{"-SUM(A1:A2)", "'-SUM(A1:A2)"},
+		{"+1-cmd|' /C calc'!A0", "'+1-cmd|' /C calc'!A0"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1", "'\t=1"},
+		{"a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		if got := escapeFormula(tt.in); got != tt.want {
+			t.Errorf("escapeFormula(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCSVLeavesNumbersAlone(t *testing.T) {
+	var out bytes.Buffer
+	e := cm.Export{
+		Format: cm.FormatCSV,
+		Header: []string{"Name", "Freight"},
+		Rows: func(write func(row []interface{}) error) error {
+			return write([]interface{}{"=cmd", decimal.RequireFromString("-1.50")})
+		},
+	}
+	if err := Write(e, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Name,Freight\n'=cmd,-1.5\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
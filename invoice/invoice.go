@@ -0,0 +1,118 @@
+//Package invoice renders an order as an HTML or PDF invoice
+package invoice
+
+import (
+	"bytes"
+	"html/template"
+	"strconv"
+	"strings"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+//Invoice is what the templates are executed with. It is stored as JSON in invoices.Snapshot when
+//issued and rendered from there, so fields may be added but not renamed
+type Invoice struct {
+	Number   string
+	IssuedAt string
+	Seller   []string
+	Footer   string
+	Currency string
+	Order    cm.Orders
+	Customer cm.Customers
+}
+
+//HTML executes the html/template file templateFile with inv
+func HTML(templateFile string, inv Invoice) ([]byte, error) {
+	t, err := template.ParseFiles(templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, inv); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//PDF lays inv out on A4 pages
+func PDF(inv Invoice) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range inv.Seller {
+		pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+	}
+
+	pdf.Ln(8)
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 8, tr("Invoice "+inv.Number), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 5, tr("Date: "+inv.IssuedAt), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, tr("Order: "+inv.Order.OrderID+" of "+inv.Order.OrderDate), "", 1, "L", false, 0, "")
+
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(0, 5, "Bill to", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	c := inv.Customer
+	for _, line := range []string{c.CompanyName, c.ContactName, c.Address, strings.TrimSpace(c.PostalCode + " " + c.City), c.Country} {
+		if line != "" {
+			pdf.CellFormat(0, 5, tr(line), "", 1, "L", false, 0, "")
+		}
+	}
+
+	widths := []float64{80, 20, 25, 20, 25}
+	pdf.Ln(6)
+	pdf.SetFont("Helvetica", "B", 10)
+	for i, h := range []string{"Product", "Quantity", "Unit price", "Discount", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		pdf.CellFormat(widths[i], 7, h, "B", 0, align, false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	for _, line := range inv.Order.OrdersDet {
+		discount := ""
+		if line.Discount.IsPositive() {
+			discount = line.Discount.Shift(2).StringFixed(0) + "%"
+		}
+		pdf.CellFormat(widths[0], 6, tr(line.ProductName), "", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[1], 6, strconv.Itoa(line.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[2], 6, line.UnitPrice.StringFixed(2), "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[3], 6, discount, "", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[4], 6, line.Amount.StringFixed(2), "", 1, "R", false, 0, "")
+	}
+
+	label := widths[0] + widths[1] + widths[2] + widths[3]
+	pdf.Ln(2)
+	pdf.CellFormat(label, 6, "Subtotal", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 6, inv.Order.Subtotal.StringFixed(2), "T", 1, "R", false, 0, "")
+	pdf.CellFormat(label, 6, "Freight", "", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 6, inv.Order.Freight.StringFixed(2), "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.CellFormat(label, 7, tr(strings.TrimSpace("Total "+inv.Currency)), "", 0, "R", false, 0, "")
+	pdf.CellFormat(widths[4], 7, inv.Order.Total.StringFixed(2), "", 1, "R", false, 0, "")
+
+	if inv.Footer != "" {
+		pdf.Ln(10)
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.MultiCell(0, 5, tr(inv.Footer), "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,49 +1,67 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
 	"Hanif_Aulia_Sabri-MyTrip/git/order/middleware"
 	"Hanif_Aulia_Sabri-MyTrip/git/order/services"
-	"Hanif_Aulia_Sabri-MyTrip/git/order/transport"
 
 	log "github.com/Sirupsen/logrus"
-	httptransport "github.com/go-kit/kit/transport/http"
 )
 
-func initHandlers() {
+//initDatabase opens one connection pool per configured datasource, shared by every handler
+func initDatabase(conf cm.Configuration) (map[string]*database.DbConnection, error) {
+	pools := map[string]*database.DbConnection{}
+
+	for name, ds := range conf.Datasources {
+		db := ds
+		var err error
+		if db.Db, err = db.Open(); err != nil {
+			closeDatabase(pools)
+			return nil, fmt.Errorf("datasource %s: %v", name, err)
+		}
+		pools[name] = &db
+	}
 
-	var svc services.PaymentServices
+	return pools, nil
+}
 
-	svc = services.PaymentService{}
-	svc = middleware.BasicMiddleware()(svc)
+//closeDatabase closes every pool opened by initDatabase
+func closeDatabase(pools map[string]*database.DbConnection) {
+	for _, db := range pools {
+		db.Close()
+	}
+}
 
-	root := cm.Config.RootURL
+func initHandlers(pools map[string]*database.DbConnection, conf cm.Configuration) (http.Handler, error) {
 
-	http.Handle(fmt.Sprintf("%s/orders", root), httptransport.NewServer(
-		transport.OrderEndpoint(svc), transport.DecodeRequest, transport.EncodeResponse,
-	))
+	var svc services.PaymentServices
 
-	//Handler baru customer
-	http.Handle(fmt.Sprintf("%s/costumer", root), httptransport.NewServer(
-		transport.CustomerEndpoint(svc), transport.DecodeCustomerRequest, transport.EncodeResponse,
-	))
+	svc = services.NewPaymentService(pools, conf)
+	svc = middleware.BasicMiddleware()(svc)
 
-	//fastpay handler
-	http.Handle(fmt.Sprintf("%s/fastpay", root), httptransport.NewServer(
-		transport.FastEndpoint(svc), transport.DecodeFastPayRequest, transport.EncodeResponse,
-	))
+	router := newRouter(svc, conf.RootURL)
 
-	http.Handle(fmt.Sprintf("%s/trips", root), httptransport.NewServer(
-		transport.TripsEndpoint(svc), transport.DecodeTripRequest, transport.EncodeResponse,
-	))
+	rules, err := loadClientAuth(conf)
+	if err != nil {
+		return nil, err
+	}
 
+	return requireClientCert(rules, router), nil
 }
 
+//defaultShutdownTimeout applies when shutdownTimeout is not configured
+const defaultShutdownTimeout = 15 * time.Second
+
 var logger *log.Entry
 
 func initLogger() {
@@ -56,21 +74,144 @@ func initLogger() {
 
 func main() {
 
-	configFile := flag.String("conf", "conf-dev.yml", "main configuration file")
-	flag.Parse()
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			os.Exit(c.run(args))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+//runServe starts the HTTP server, it is the default command
+func runServe(args []string) int {
+
+	fs, configFile := newFlagSet("serve")
+	checkConfig := fs.Bool("check-config", false, "validate the configuration and exit without starting the server")
+	fs.Parse(args)
+
+	if *checkConfig {
+		return checkConfiguration(*configFile)
+	}
+
 	initLogger()
 	log.WithField("file", *configFile).Info("Loading configuration file")
 	cm.LoadConfigFromFile(configFile)
-	initHandlers()
 
-	var err error
-	if cm.Config.RootURL != "" || cm.Config.ListenPort != "" {
-		err = http.ListenAndServe(cm.Config.ListenPort, nil)
+	pools, err := initDatabase(cm.Config)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to open database connection")
+		return 1
+	}
+
+	mux, err := initHandlers(pools, cm.Config)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to register handlers")
+		closeDatabase(pools)
+		return 1
+	}
+
+	handler := &handlerSwitch{}
+	handler.Store(mux)
+
+	srv := &http.Server{
+		Addr:    cm.Config.ListenPort,
+		Handler: handler,
 	}
 
+	r := newReloader(*configFile, handler, pools)
+
+	if cm.Config.TLS.Enabled() {
+		if srv.TLSConfig, r.certs, err = newTLSConfig(cm.Config.TLS); err != nil {
+			log.WithField("error", err).Error("Unable to load TLS certificate")
+			closeDatabase(pools)
+			return 1
+		}
+	}
+
+	go r.watch()
+
+	err = serve(srv, cm.Config.ShutdownTimeout)
+
+	r.close()
+
 	if err != nil {
 		log.WithField("error", err).Error("Unable to start the server")
-		os.Exit(1)
+		return 1
+	}
+
+	return 0
+}
+
+//checkConfiguration prints every configuration problem and returns the process exit code
+func checkConfiguration(file string) int {
+	c, err := cm.LoadConfig(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := c.Validate(); err != nil {
+		errs := err.(cm.ConfigErrors)
+		for _, ce := range errs {
+			fmt.Fprintln(os.Stderr, ce.Error())
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d problem(s) found\n", file, len(errs))
+		return 1
+	}
+
+	fmt.Printf("%s: configuration OK\n", file)
+	return 0
+}
+
+//serve runs srv until it fails or SIGINT/SIGTERM is received, in which case
+//it stops accepting connections and waits up to timeout for in-flight requests
+func serve(srv *http.Server, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			log.WithField("listenPort", srv.Addr).Info("Server started with TLS")
+			errs <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		log.WithField("listenPort", srv.Addr).Info("Server started")
+		errs <- srv.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-errs:
+		return err
+	case sig := <-stop:
+		log.WithField("signal", sig.String()).WithField("timeout", timeout.String()).Info("Shutting down, draining in-flight requests")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.WithField("error", err).Warn("Shutdown timed out, closing remaining connections")
+		srv.Close()
+	}
+
+	if err := <-errs; err != http.ErrServerClosed {
+		return err
 	}
 
+	log.Info("Server stopped")
+	return nil
 }
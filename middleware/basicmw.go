@@ -17,6 +17,8 @@ func BasicMiddleware() services.ServiceMiddleware {
 	}
 }
 
+//BasicMiddlewareStruct logs every handler call, HealthHandler and ReadinessHandler
+//are passed through unlogged as load balancers poll them every few seconds
 type BasicMiddlewareStruct struct {
 	services.PaymentServices
 }
@@ -33,6 +35,138 @@ func (mw BasicMiddlewareStruct) OrderHandler(ctx context.Context, request cm.Mes
 
 }
 
+func (mw BasicMiddlewareStruct) CreateOrderHandler(ctx context.Context, request cm.Orders) cm.Message {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("CreateOrderHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("CreateOrderHandler begins")
+
+	return mw.PaymentServices.CreateOrderHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) ListOrdersHandler(ctx context.Context, request cm.OrderFilter) cm.OrderList {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("ListOrdersHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("ListOrdersHandler begins")
+
+	return mw.PaymentServices.ListOrdersHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) UpdateOrderLinesHandler(ctx context.Context, request cm.OrderUpdate) cm.Message {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("UpdateOrderLinesHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("UpdateOrderLinesHandler begins")
+
+	return mw.PaymentServices.UpdateOrderLinesHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) UpdateShippingHandler(ctx context.Context, request cm.OrderUpdate) cm.Message {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("UpdateShippingHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("UpdateShippingHandler begins")
+
+	return mw.PaymentServices.UpdateShippingHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) CancelOrderHandler(ctx context.Context, request cm.OrderUpdate) cm.Message {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("CancelOrderHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("CancelOrderHandler begins")
+
+	return mw.PaymentServices.CancelOrderHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) TransitionOrderHandler(ctx context.Context, request cm.OrderUpdate) cm.Message {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("TransitionOrderHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("TransitionOrderHandler begins")
+
+	return mw.PaymentServices.TransitionOrderHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) OrderHistoryHandler(ctx context.Context, request cm.Message) cm.StatusHistory {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("OrderHistoryHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("OrderHistoryHandler begins")
+
+	return mw.PaymentServices.OrderHistoryHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) InvoiceHandler(ctx context.Context, request cm.InvoiceRequest) cm.Document {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("InvoiceHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("InvoiceHandler begins")
+
+	return mw.PaymentServices.InvoiceHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) IssueInvoiceHandler(ctx context.Context, request cm.InvoiceRequest) cm.Document {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("IssueInvoiceHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("IssueInvoiceHandler begins")
+
+	return mw.PaymentServices.IssueInvoiceHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) ExportOrdersHandler(ctx context.Context, request cm.OrderExport) cm.Export {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("ExportOrdersHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("ExportOrdersHandler begins")
+
+	return mw.PaymentServices.ExportOrdersHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) ExportCustomersHandler(ctx context.Context, request cm.CustomerExport) cm.Export {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("ExportCustomersHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("ExportCustomersHandler begins")
+
+	return mw.PaymentServices.ExportCustomersHandler(ctx, request)
+
+}
+
 func (mw BasicMiddlewareStruct) CustomerHandler(ctx context.Context, request cm.Customers) cm.Customers {
 
 	defer func(begin time.Time) {
@@ -45,6 +179,114 @@ func (mw BasicMiddlewareStruct) CustomerHandler(ctx context.Context, request cm.
 
 }
 
+func (mw BasicMiddlewareStruct) GetCustomerHandler(ctx context.Context, request cm.Customers) cm.CustomerResult {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("GetCustomerHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("GetCustomerHandler begins")
+
+	return mw.PaymentServices.GetCustomerHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) SearchCustomersHandler(ctx context.Context, request cm.CustomerSearch) cm.CustomerList {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("SearchCustomersHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("SearchCustomersHandler begins")
+
+	return mw.PaymentServices.SearchCustomersHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) CustomerOrdersHandler(ctx context.Context, request cm.OrderFilter) cm.CustomerOrders {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("CustomerOrdersHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("CustomerOrdersHandler begins")
+
+	return mw.PaymentServices.CustomerOrdersHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) FindDuplicatesHandler(ctx context.Context, request cm.DuplicateSearch) cm.DuplicateList {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("FindDuplicatesHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("FindDuplicatesHandler begins")
+
+	return mw.PaymentServices.FindDuplicatesHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) MergeCustomerHandler(ctx context.Context, request cm.CustomerMerge) cm.MergeResult {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("MergeCustomerHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("MergeCustomerHandler begins")
+
+	return mw.PaymentServices.MergeCustomerHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) CreateCustomerHandler(ctx context.Context, request cm.Customers) cm.CustomerResult {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("CreateCustomerHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("CreateCustomerHandler begins")
+
+	return mw.PaymentServices.CreateCustomerHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) UpdateCustomerHandler(ctx context.Context, request cm.Customers) cm.CustomerResult {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("UpdateCustomerHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("UpdateCustomerHandler begins")
+
+	return mw.PaymentServices.UpdateCustomerHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) PatchCustomerHandler(ctx context.Context, request cm.CustomerPatch) cm.CustomerResult {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("PatchCustomerHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("PatchCustomerHandler begins")
+
+	return mw.PaymentServices.PatchCustomerHandler(ctx, request)
+
+}
+
+func (mw BasicMiddlewareStruct) DeleteCustomerHandler(ctx context.Context, request cm.Customers) cm.CustomerResult {
+
+	defer func(begin time.Time) {
+		log.WithField("execTime", float64(time.Since(begin).Nanoseconds())/float64(1e6)).Info("DeleteCustomerHandler ends")
+	}(time.Now())
+
+	log.WithField("request", request).Info("DeleteCustomerHandler begins")
+
+	return mw.PaymentServices.DeleteCustomerHandler(ctx, request)
+
+}
+
 func (mw BasicMiddlewareStruct) FastPayHandler(ctx context.Context, request cm.FastPayRequest) cm.FastPayResponse {
 
 	defer func(begin time.Time) {
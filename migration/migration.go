@@ -0,0 +1,141 @@
+package migration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+)
+
+//Migration is one schema change read from a file named <version>_<name>.sql
+type Migration struct {
+	Version    string
+	Name       string
+	Statements []string
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+const createTable = "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+	"`version` VARCHAR(32) NOT NULL PRIMARY KEY, " +
+	"`name` VARCHAR(255) NOT NULL, " +
+	"`applied_at` DATETIME NOT NULL)"
+
+//Load reads every migration in dir ordered by version. Statements in a file
+//are separated by a semicolon at the end of a line.
+func Load(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	seen := map[string]string{}
+	for _, f := range files {
+		m := fileName.FindStringSubmatch(f.Name())
+		if f.IsDir() || m == nil {
+			continue
+		}
+		if prev, ok := seen[m[1]]; ok {
+			return nil, fmt.Errorf("version %s used by both %s and %s", m[1], prev, f.Name())
+		}
+		seen[m[1]] = f.Name()
+
+		raw, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, Migration{
+			Version:    m[1],
+			Name:       m[2],
+			Statements: split(string(raw)),
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func split(raw string) []string {
+	var stmts []string
+	var buf strings.Builder
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			if s := strings.TrimSpace(buf.String()); s != ";" {
+				stmts = append(stmts, strings.TrimSuffix(s, ";"))
+			}
+			buf.Reset()
+		}
+	}
+	if s := strings.TrimSpace(buf.String()); s != "" {
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+//Pending returns the migrations not yet recorded in schema_migrations, creating that table if needed
+func Pending(db *database.DbConnection, migrations []Migration) ([]Migration, error) {
+	if _, err := db.Exec(createTable); err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+//Apply runs the statements of m and records it in schema_migrations.
+//MySQL commits DDL implicitly, so a failing migration may be partially applied.
+func Apply(db *database.DbConnection, m Migration) error {
+	tx, err := db.Db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for i, stmt := range m.Statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s_%s statement %d: %v", m.Version, m.Name, i+1, err)
+		}
+	}
+
+	if _, err := tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, now())", m.Version, m.Name); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,7 @@
+-- trips fetched from the provider and stored by TripsHandler
+CREATE TABLE IF NOT EXISTS `trip` (
+	`TripRowID` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	`AirlineName` VARCHAR(255) NULL,
+	`AirportName` VARCHAR(255) NULL,
+	`CityName` VARCHAR(255) NULL
+);
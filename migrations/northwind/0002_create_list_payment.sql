@@ -0,0 +1,7 @@
+-- payment channels per merchant, read by FastPayHandler
+CREATE TABLE IF NOT EXISTS `list_payment` (
+	`merchant_id` VARCHAR(32) NOT NULL,
+	`pg_code` VARCHAR(32) NOT NULL,
+	`pg_name` VARCHAR(255) NULL,
+	PRIMARY KEY (`merchant_id`, `pg_code`)
+);
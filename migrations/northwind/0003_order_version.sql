@@ -0,0 +1,5 @@
+-- Version guards order updates against concurrent edits, it is sent back as the ETag
+-- and bumped by every change. Status is 'open' until the order is cancelled.
+ALTER TABLE `orders`
+	ADD COLUMN `Version` INT NOT NULL DEFAULT 1,
+	ADD COLUMN `Status` VARCHAR(16) NOT NULL DEFAULT 'open';
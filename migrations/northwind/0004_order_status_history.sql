@@ -0,0 +1,16 @@
+-- Orders move through pending_payment -> paid -> shipped, or to cancelled before shipping.
+-- Existing open orders are shipped when they have a ShippedDate, otherwise pending payment.
+UPDATE `orders` SET `Status` = 'shipped' WHERE `Status` = 'open' AND `ShippedDate` IS NOT NULL;
+UPDATE `orders` SET `Status` = 'pending_payment' WHERE `Status` = 'open';
+ALTER TABLE `orders` ALTER COLUMN `Status` SET DEFAULT 'pending_payment';
+
+-- every status change, FromStatus is NULL for the status an order is created with
+CREATE TABLE IF NOT EXISTS `order_status_history` (
+	`HistoryID` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	`OrderID` INT NOT NULL,
+	`FromStatus` VARCHAR(16) NULL,
+	`ToStatus` VARCHAR(16) NOT NULL,
+	`Reason` VARCHAR(255) NULL,
+	`ChangedAt` DATETIME NOT NULL,
+	KEY `order_status_history_order` (`OrderID`, `HistoryID`)
+);
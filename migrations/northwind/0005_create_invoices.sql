@@ -0,0 +1,10 @@
+-- one invoice per order, InvoiceNo is allocated as MAX + 1 under lock so numbers have no gaps.
+-- Snapshot is the invoice as issued (JSON of invoice.Invoice), it is rendered instead of the
+-- live order and customer so an issued invoice never changes
+CREATE TABLE IF NOT EXISTS `invoices` (
+	`InvoiceNo` INT NOT NULL PRIMARY KEY,
+	`OrderID` INT NOT NULL,
+	`IssuedAt` DATETIME NOT NULL,
+	`Snapshot` MEDIUMTEXT NOT NULL,
+	UNIQUE KEY `invoices_order` (`OrderID`)
+);
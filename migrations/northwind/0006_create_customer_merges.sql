@@ -0,0 +1,12 @@
+-- one row per merged customer, Duplicate keeps the removed customer as JSON
+CREATE TABLE IF NOT EXISTS `customer_merges` (
+	`MergeID` INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	`SurvivorID` VARCHAR(5) NOT NULL,
+	`DuplicateID` VARCHAR(5) NOT NULL,
+	`Duplicate` TEXT NOT NULL,
+	`OrdersMoved` INT NOT NULL,
+	`Reason` VARCHAR(255) NULL,
+	`MergedAt` DATETIME NOT NULL,
+	KEY `customer_merges_survivor` (`SurvivorID`),
+	KEY `customer_merges_duplicate` (`DuplicateID`)
+);
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//generation is one set of routes and the requests it is still serving
+type generation struct {
+	handler http.Handler
+	active  sync.WaitGroup
+}
+
+//handlerSwitch serves every request with the handler stored last
+type handlerSwitch struct {
+	mu      sync.RWMutex
+	current *generation
+}
+
+//Store serves every later request with next and returns the generation it replaces
+func (h *handlerSwitch) Store(next http.Handler) *generation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev := h.current
+	h.current = &generation{handler: next}
+	return prev
+}
+
+func (h *handlerSwitch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	//counted under the lock, so once Store returns no request starts on the replaced generation
+	h.mu.RLock()
+	g := h.current
+	g.active.Add(1)
+	h.mu.RUnlock()
+
+	defer g.active.Done()
+	g.handler.ServeHTTP(w, r)
+}
+
+//reloader re-reads the configuration file on SIGHUP or when it changes on disk
+//and swaps the live configuration, connection pools and routes in one step
+type reloader struct {
+	file    string
+	handler *handlerSwitch
+	certs   *certLoader
+
+	mu      sync.Mutex
+	pools   poolSet
+	retired map[*poolSet]bool
+	modTime time.Time
+
+	//replaced generations that still serve requests with pools
+	users []*generation
+}
+
+//poolSet maps datasource names to their open connection pool
+type poolSet = map[string]*database.DbConnection
+
+func newReloader(file string, handler *handlerSwitch, current poolSet) *reloader {
+	r := &reloader{file: file, handler: handler, pools: current, retired: map[*poolSet]bool{}}
+	r.modTime, _ = r.stat()
+	return r
+}
+
+func (r *reloader) stat() (time.Time, error) {
+	if r.file == "" {
+		return time.Time{}, nil
+	}
+	fi, err := os.Stat(r.file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+//watch blocks, reloading on SIGHUP and, when reloadInterval is set, on file change
+func (r *reloader) watch() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	interval := cm.Current().ReloadInterval
+	var tick <-chan time.Time
+	if interval > 0 && r.file != "" {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case <-hup:
+			r.reload("signal")
+		case <-tick:
+			modTime, err := r.stat()
+			if err != nil {
+				log.WithField("error", err).Warn("Unable to check configuration file")
+				continue
+			}
+			if !modTime.Equal(r.modTime) {
+				r.modTime = modTime
+				r.reload("file changed")
+			}
+		}
+	}
+}
+
+//reload applies the configuration file if it is valid, the running one is kept otherwise
+func (r *reloader) reload(reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := log.WithField("file", r.file).WithField("reason", reason)
+
+	next, err := cm.LoadConfig(r.file)
+	if err == nil {
+		err = next.Validate()
+	}
+	if err != nil {
+		entry.WithField("error", err.Error()).Error("Configuration reload rejected")
+		return
+	}
+
+	prev := cm.Current()
+	if next.ListenPort != prev.ListenPort {
+		entry.WithField("listenPort", prev.ListenPort).Warn("listenPort change needs a restart, keeping the current one")
+		next.ListenPort = prev.ListenPort
+	}
+	if next.TLS.Enabled() != prev.TLS.Enabled() {
+		entry.Warn("Switching between HTTP and HTTPS needs a restart, keeping the current tls settings")
+		next.TLS = prev.TLS
+	}
+
+	current := r.pools
+	changed := !reflect.DeepEqual(next.Datasources, prev.Datasources)
+	if changed {
+		if current, err = initDatabase(next); err != nil {
+			entry.WithField("error", err).Error("Configuration reload rejected, unable to open database connection")
+			return
+		}
+	}
+
+	mux, err := initHandlers(current, next)
+	if err != nil {
+		if changed {
+			closeDatabase(current)
+		}
+		entry.WithField("error", err).Error("Configuration reload rejected, unable to register handlers")
+		return
+	}
+
+	cm.SetCurrent(next)
+	replaced := r.handler.Store(mux)
+
+	if r.certs != nil {
+		r.certs.Reload()
+	}
+
+	if changed {
+		old := r.pools
+		r.pools = current
+		r.retired[&old] = true
+		go r.closeWhenDrained(&old, append(r.users, replaced), next.ShutdownTimeout)
+		r.users = nil
+	} else {
+		r.users = append(r.users, replaced)
+	}
+
+	entry.WithField("config", next.Masked()).Info("Configuration reload applied")
+}
+
+//closeWhenDrained closes old once the generations using it have finished their requests,
+//or after timeout when some of them are still running
+func (r *reloader) closeWhenDrained(old *poolSet, users []*generation, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		for _, g := range users {
+			g.active.Wait()
+		}
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		log.WithField("timeout", timeout.String()).Warn("Closing replaced database connections with requests still running")
+	}
+
+	r.closeRetired(old)
+}
+
+func (r *reloader) closeRetired(old *poolSet) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.retired[old] {
+		delete(r.retired, old)
+		closeDatabase(*old)
+	}
+}
+
+//close releases the connection pool in use and any replaced one still draining
+func (r *reloader) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for old := range r.retired {
+		closeDatabase(*old)
+	}
+	r.retired = map[*poolSet]bool{}
+
+	closeDatabase(r.pools)
+	log.Info("Database connections closed")
+}
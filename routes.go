@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/services"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/transport"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+)
+
+//route is one path registered by initHandlers under rootUrl, path parameters such as {orderID}
+//reach the decoder through mux.Vars. A nil encoder means transport.EncodeResponse.
+type route struct {
+	method   string
+	path     string
+	endpoint func(services.PaymentServices) endpoint.Endpoint
+	decoder  httptransport.DecodeRequestFunc
+	encoder  httptransport.EncodeResponseFunc
+}
+
+var routes = []route{
+	{"GET", "/orders", transport.ListOrdersEndpoint, transport.DecodeListOrdersRequest, nil},
+	{"GET", "/orders/export", transport.ExportOrdersEndpoint, transport.DecodeExportOrdersRequest, transport.EncodeExportResponse},
+	{"GET", "/orders/{orderID:[0-9]+}", transport.OrderEndpoint, transport.DecodeOrderIDRequest, transport.EncodeOrderResponse},
+	{"POST", "/orders", transport.CreateOrderEndpoint, transport.DecodeCreateOrderRequest, transport.EncodeOrderResponse},
+	//deprecated: the lookup by body POST /orders answered before it created orders, kept for existing clients
+	{"POST", "/orders/lookup", transport.OrderEndpoint, transport.DecodeRequest, transport.EncodeDeprecatedResponse("GET /orders/{orderID}")},
+	//updates need the current version as If-Match or "version" in the body
+	{"PUT", "/orders/{orderID:[0-9]+}/lines", transport.UpdateOrderLinesEndpoint, transport.DecodeOrderUpdateRequest, transport.EncodeOrderResponse},
+	{"PUT", "/orders/{orderID:[0-9]+}/shipping", transport.UpdateShippingEndpoint, transport.DecodeOrderUpdateRequest, transport.EncodeOrderResponse},
+	{"POST", "/orders/{orderID:[0-9]+}/cancel", transport.CancelOrderEndpoint, transport.DecodeOrderUpdateRequest, transport.EncodeOrderResponse},
+	{"POST", "/orders/{orderID:[0-9]+}/status", transport.TransitionOrderEndpoint, transport.DecodeOrderUpdateRequest, transport.EncodeOrderResponse},
+	{"GET", "/orders/{orderID:[0-9]+}/history", transport.OrderHistoryEndpoint, transport.DecodeOrderIDRequest, nil},
+	{"GET", "/orders/{orderID:[0-9]+}/invoice", transport.InvoiceEndpoint, transport.DecodeInvoiceRequest, transport.EncodeDocumentResponse},
+	{"POST", "/orders/{orderID:[0-9]+}/invoice", transport.IssueInvoiceEndpoint, transport.DecodeInvoiceRequest, transport.EncodeDocumentResponse},
+
+	//before {customerID} so export and duplicates are not taken for ids
+	{"GET", "/customers/export", transport.ExportCustomersEndpoint, transport.DecodeExportCustomersRequest, transport.EncodeExportResponse},
+	{"GET", "/customers/duplicates", transport.FindDuplicatesEndpoint, transport.DecodeFindDuplicatesRequest, nil},
+	{"GET", "/customers", transport.SearchCustomersEndpoint, transport.DecodeSearchCustomersRequest, nil},
+	{"POST", "/customers", transport.CreateCustomerEndpoint, transport.DecodeCustomerRequest, nil},
+	{"GET", "/customers/{customerID}", transport.GetCustomerEndpoint, transport.DecodeCustomerIDRequest, nil},
+	{"PUT", "/customers/{customerID}", transport.UpdateCustomerEndpoint, transport.DecodeCustomerUpdateRequest, nil},
+	{"PATCH", "/customers/{customerID}", transport.PatchCustomerEndpoint, transport.DecodeCustomerPatchRequest, nil},
+	{"DELETE", "/customers/{customerID}", transport.DeleteCustomerEndpoint, transport.DecodeCustomerIDRequest, nil},
+	{"GET", "/customers/{customerID}/orders", transport.CustomerOrdersEndpoint, transport.DecodeCustomerOrdersRequest, nil},
+	{"POST", "/customers/{customerID}/merge", transport.MergeCustomerEndpoint, transport.DecodeMergeCustomerRequest, nil},
+	//Handler baru customer, deprecated: kept with its old response for existing clients
+	{"POST", "/costumer", transport.CustomerEndpoint, transport.DecodeCustomerRequest, transport.EncodeDeprecatedResponse("GET /customers/{customerID}")},
+
+	//fastpay handler
+	{"POST", "/fastpay", transport.FastEndpoint, transport.DecodeFastPayRequest, nil},
+
+	{"POST", "/trips", transport.TripsEndpoint, transport.DecodeTripRequest, nil},
+
+	//load balancer probes
+	{"GET", "/healthz", transport.HealthEndpoint, transport.DecodeEmptyRequest, transport.EncodeHealthResponse},
+	{"GET", "/readyz", transport.ReadinessEndpoint, transport.DecodeEmptyRequest, transport.EncodeHealthResponse},
+}
+
+//newRouter registers every route for svc under root, unknown paths get a 404
+//and known paths called with the wrong method a 405, both as JSON
+func newRouter(svc services.PaymentServices, root string) *mux.Router {
+	router := mux.NewRouter()
+
+	for _, rt := range routes {
+		encoder := rt.encoder
+		if encoder == nil {
+			encoder = transport.EncodeResponse
+		}
+		router.Methods(rt.method).Path(root + rt.path).Handler(httptransport.NewServer(
+			rt.endpoint(svc), rt.decoder, encoder,
+		))
+	}
+
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		transport.EncodeError(w, http.StatusNotFound, cm.CodePathNotFound, "Path not found")
+	})
+
+	router.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(allowedMethods(router, r.URL.Path), ", "))
+		transport.EncodeError(w, http.StatusMethodNotAllowed, cm.CodeMethodNotAllowed, "Method not allowed")
+	})
+
+	return router
+}
+
+//allowedMethods lists the methods of every route matching path
+func allowedMethods(router *mux.Router, path string) []string {
+	var allowed []string
+	router.Walk(func(rt *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		re, err := rt.GetPathRegexp()
+		if err != nil {
+			return nil
+		}
+		if matched, _ := regexp.MatchString(re, path); matched {
+			methods, _ := rt.GetMethods()
+			allowed = append(allowed, methods...)
+		}
+		return nil
+	})
+	sort.Strings(allowed)
+	return allowed
+}
+
+//printRoutes writes every route with the decoder and endpoint serving it
+func printRoutes(w io.Writer, root string) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tDECODER\tENDPOINT")
+	for _, rt := range routes {
+		fmt.Fprintf(tw, "%s\t%s%s\t%s\t%s\n", rt.method, root, rt.path, funcName(rt.decoder), funcName(rt.endpoint))
+	}
+	tw.Flush()
+}
+
+//funcName returns the package qualified name of fn, e.g. transport.DecodeRequest
+func funcName(fn interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
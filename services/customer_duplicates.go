@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+//defaultDuplicateScore is the score a pair needs when DuplicateSearch.MinScore is not set
+const defaultDuplicateScore = 0.75
+
+//phoneKeyDigits is how many trailing digits of a phone are compared, so national and
+//international forms of one number meet
+const phoneKeyDigits = 8
+
+//weights of the compared parts, the score is their weighted sum
+const (
+	dupNameWeight    = 0.5
+	dupPhoneWeight   = 0.3
+	dupAddressWeight = 0.2
+)
+
+//unknownSimilarity stands in for a part empty on either side, neither for nor against a match
+const unknownSimilarity = 0.5
+
+//similarEnough is the similarity from which a part is named in DuplicatePair.Reasons
+const similarEnough = 0.8
+
+//legalForms are dropped from company names, "Ernst Handel GmbH" and "Ernst Handel" are the same company
+var legalForms = map[string]bool{
+	"the": true, "and": true, "und": true, "et": true, "y": true,
+	"inc": true, "ltd": true, "llc": true, "co": true, "corp": true, "company": true,
+	"gmbh": true, "ag": true, "kg": true, "sa": true, "sl": true, "srl": true, "spa": true,
+	"bv": true, "nv": true, "ab": true, "as": true, "oy": true, "pt": true, "tbk": true, "cv": true,
+}
+
+//streetWords are shortened so "Obere Strasse 57" and "Obere Str. 57" compare equal
+var streetWords = map[string]string{
+	"street": "st", "strasse": "str", "straße": "str", "avenue": "av", "ave": "av", "avda": "av",
+	"road": "rd", "boulevard": "blvd", "calle": "c", "rua": "r", "place": "pl", "plaza": "pl",
+}
+
+//minBlockWord is how long a name word must be for customers sharing it to be compared
+const minBlockWord = 3
+
+//maxBlockSize bounds the customers compared within one block. Every pair of a block is
+//scored, so a block of a word like "restaurant" shared by thousands of customers would
+//take millions of comparisons. Larger blocks are skipped, their members still meet in
+//their other blocks.
+const maxBlockSize = 200
+
+//duplicateKey is a customer with its compared parts normalized
+type duplicateKey struct {
+	customer cm.Customers
+	name     string
+	words    []string
+	phone    string
+	address  string
+	postal   string
+}
+
+func newDuplicateKey(c cm.Customers) duplicateKey {
+	k := duplicateKey{customer: c}
+
+	for _, w := range normalizedWords(c.CompanyName) {
+		if !legalForms[w] {
+			k.words = append(k.words, w)
+		}
+	}
+	k.name = strings.Join(k.words, " ")
+
+	k.phone = onlyDigits(c.Phone)
+	if len(k.phone) > phoneKeyDigits {
+		k.phone = k.phone[len(k.phone)-phoneKeyDigits:]
+	} else if len(k.phone) < phoneKeyDigits-2 {
+		k.phone = ""
+	}
+
+	words := normalizedWords(c.Address)
+	for i, w := range words {
+		if short, ok := streetWords[w]; ok {
+			words[i] = short
+		}
+	}
+	k.address = strings.Join(words, " ")
+	k.postal = strings.Join(normalizedWords(c.PostalCode), "")
+
+	return k
+}
+
+//normalizedWords lower cases s and splits it on everything but letters and digits
+func normalizedWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+//findDuplicates compares customers that share a name word, a phone or a postal code
+//and returns the pairs scoring at least minScore, best first. skipped lists the blocks
+//left out for having more than maxBlockSize members.
+func findDuplicates(customers []cm.Customers, minScore float64) (pairs []cm.DuplicatePair, skipped []string) {
+	keys := make([]duplicateKey, len(customers))
+	blocks := map[string][]int{}
+	for i, c := range customers {
+		keys[i] = newDuplicateKey(c)
+		k := keys[i]
+
+		for _, w := range k.words {
+			if len([]rune(w)) >= minBlockWord {
+				blocks["name:"+w] = append(blocks["name:"+w], i)
+			}
+		}
+		if k.phone != "" {
+			blocks["phone:"+k.phone] = append(blocks["phone:"+k.phone], i)
+		}
+		if k.postal != "" {
+			block := "postal:" + strings.ToLower(c.Country) + ":" + k.postal
+			blocks[block] = append(blocks[block], i)
+		}
+	}
+
+	seen := map[[2]int]bool{}
+	pairs = []cm.DuplicatePair{}
+	for block, members := range blocks {
+		if len(members) > maxBlockSize {
+			skipped = append(skipped, block)
+			continue
+		}
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				pair := [2]int{members[x], members[y]}
+				if seen[pair] {
+					continue
+				}
+				seen[pair] = true
+
+				score, reasons := duplicateScore(keys[pair[0]], keys[pair[1]])
+				if score >= minScore {
+					pairs = append(pairs, cm.DuplicatePair{
+						Customers: [2]cm.Customers{customers[pair[0]], customers[pair[1]]},
+						Score:     score,
+						Reasons:   reasons,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].Customers[0].CustomerID != pairs[j].Customers[0].CustomerID {
+			return pairs[i].Customers[0].CustomerID < pairs[j].Customers[0].CustomerID
+		}
+		return pairs[i].Customers[1].CustomerID < pairs[j].Customers[1].CustomerID
+	})
+	sort.Strings(skipped)
+	return pairs, skipped
+}
+
+//duplicateScore weighs how alike the names, phones and addresses of a and b are, from 0 to 1
+func duplicateScore(a, b duplicateKey) (float64, []string) {
+	reasons := []string{}
+
+	name := unknownSimilarity
+	if a.name != "" && b.name != "" {
+		name = math.Max(similarity(a.name, b.name), wordOverlap(a.words, b.words))
+		if name >= similarEnough {
+			reasons = append(reasons, "CompanyName")
+		}
+	}
+
+	phone := unknownSimilarity
+	if a.phone != "" && b.phone != "" {
+		phone = 0
+		if a.phone == b.phone {
+			phone = 1
+			reasons = append(reasons, "Phone")
+		}
+	}
+
+	address := unknownSimilarity
+	if a.address != "" && b.address != "" {
+		address = similarity(a.address, b.address)
+		if a.postal != "" && b.postal != "" && a.postal != b.postal {
+			address /= 2
+		}
+		if address >= similarEnough {
+			reasons = append(reasons, "Address")
+		}
+	}
+
+	score := dupNameWeight*name + dupPhoneWeight*phone + dupAddressWeight*address
+	return math.Round(score*1000) / 1000, reasons
+}
+
+//similarity is 1 minus the edit distance of a and b relative to the longer one
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+//wordOverlap is the share of words a and b have in common, whatever their order
+func wordOverlap(a, b []string) float64 {
+	in := map[string]bool{}
+	for _, w := range a {
+		in[w] = true
+	}
+	all := len(in)
+	common := 0
+	for _, w := range b {
+		if in[w] {
+			common++
+			delete(in, w)
+		} else {
+			all++
+		}
+	}
+	if all == 0 {
+		return 0
+	}
+	return float64(common) / float64(all)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func duplicateScoreRemark(minScore float64) string {
+	if minScore < 0 || minScore > 1 {
+		return fmt.Sprintf("minScore must be between 0 and 1, got %v", minScore)
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "", 1},
+		{"alfreds", "alfreds", 1},
+		{"alfreds", "", 0},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"obere str 57", "obere str 75", 1 - 2.0/12},
+		{"straße", "strasse", 1 - 2.0/7},
+		{"abc", "xyz", 0},
+	}
+	for _, tt := range tests {
+		got := similarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if back := similarity(tt.b, tt.a); back != got {
+			t.Errorf("similarity(%q, %q) = %v, but %v the other way", tt.a, tt.b, got, back)
+		}
+	}
+}
+
+func TestWordOverlap(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want float64
+	}{
+		{nil, nil, 0},
+		{[]string{"ernst", "handel"}, []string{"handel", "ernst"}, 1},
+		{[]string{"ernst", "handel"}, []string{"ernst"}, 0.5},
+		{[]string{"blauer", "see"}, []string{"see", "delikatessen"}, 1.0 / 3},
+		{[]string{"a"}, []string{"b"}, 0},
+	}
+	for _, tt := range tests {
+		if got := wordOverlap(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("wordOverlap(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	alfki := cm.Customers{CustomerID: "ALFKI", CompanyName: "Alfreds Futterkiste", Address: "Obere Str. 57",
+		Country: "Germany", PostalCode: "12209", Phone: "030-0074321"}
+	alfkg := cm.Customers{CustomerID: "ALFKG", CompanyName: "Alfreds Futterkiste GmbH", Address: "Obere Strasse 57",
+		Country: "Germany", PostalCode: "12209", Phone: "+49 30 0074321"}
+	blaus := cm.Customers{CustomerID: "BLAUS", CompanyName: "Blauer See Delikatessen", Country: "Germany"}
+	blaud := cm.Customers{CustomerID: "BLAUD", CompanyName: "Blauer See Delikatessen", Country: "Germany"}
+	anatr := cm.Customers{CustomerID: "ANATR", CompanyName: "Ana Trujillo Emparedados y helados", Address: "Avda. de la Constitución 2222",
+		Country: "Mexico", PostalCode: "05021", Phone: "(5) 555-4729"}
+	antonio := cm.Customers{CustomerID: "ANTON", CompanyName: "Antonio Moreno Taquería", Address: "Mataderos 2312",
+		Country: "Mexico", PostalCode: "05023", Phone: "(5) 555-3932"}
+	moved := cm.Customers{CustomerID: "ALFKM", CompanyName: "Alfreds Futterkiste", Address: "Unter den Linden 1",
+		Country: "Germany", PostalCode: "10117", Phone: "030-9999999"}
+
+	type pair struct {
+		a, b    string
+		score   float64
+		reasons []string
+	}
+	tests := []struct {
+		name      string
+		customers []cm.Customers
+		minScore  float64
+		want      []pair
+	}{
+		{
+			name:      "legal form, street word and phone form ignored",
+			customers: []cm.Customers{alfki, anatr, alfkg},
+			minScore:  defaultDuplicateScore,
+			want:      []pair{{"ALFKI", "ALFKG", 1, []string{"CompanyName", "Phone", "Address"}}},
+		},
+		{
+			name:      "missing phone and address are neutral",
+			customers: []cm.Customers{blaus, blaud},
+			minScore:  defaultDuplicateScore,
+			want:      []pair{{"BLAUS", "BLAUD", 0.75, []string{"CompanyName"}}},
+		},
+		{
+			name:      "same name elsewhere scores below the default",
+			customers: []cm.Customers{alfki, moved},
+			minScore:  defaultDuplicateScore,
+			want:      []pair{},
+		},
+		{
+			name:      "minScore lets weaker pairs through, best first",
+			customers: []cm.Customers{alfki, moved, alfkg},
+			minScore:  0.5,
+			want: []pair{
+				{"ALFKI", "ALFKG", 1, []string{"CompanyName", "Phone", "Address"}},
+				{"ALFKI", "ALFKM", 0.522, []string{"CompanyName"}},
+				{"ALFKM", "ALFKG", 0.522, []string{"CompanyName"}},
+			},
+		},
+		{
+			name:      "customers sharing no block are not compared",
+			customers: []cm.Customers{anatr, antonio},
+			minScore:  0,
+			want:      []pair{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, skipped := findDuplicates(tt.customers, tt.minScore)
+			if len(skipped) > 0 {
+				t.Errorf("skipped blocks %v", skipped)
+			}
+			got := []pair{}
+			for _, p := range pairs {
+				got = append(got, pair{p.Customers[0].CustomerID, p.Customers[1].CustomerID, p.Score, p.Reasons})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got pairs %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesSkipsLargeBlocks(t *testing.T) {
+	customers := make([]cm.Customers, maxBlockSize+1)
+	for i := range customers {
+		customers[i] = cm.Customers{CustomerID: "C" + strconv.Itoa(i), CompanyName: "Restaurante Central", Country: "Spain"}
+	}
+	customers = append(customers,
+		cm.Customers{CustomerID: "GALED", CompanyName: "Galería del gastrónomo", Phone: "+34 93 203 4560"},
+		cm.Customers{CustomerID: "GALEX", CompanyName: "Galeria del Gastronomo", Phone: "(93) 203 4560", Country: "Spain"})
+
+	pairs, skipped := findDuplicates(customers, defaultDuplicateScore)
+	if want := []string{"name:central", "name:restaurante"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped %v, want %v", skipped, want)
+	}
+	if len(pairs) != 1 || pairs[0].Customers[0].CustomerID != "GALED" || pairs[0].Customers[1].CustomerID != "GALEX" {
+		t.Errorf("got pairs %v, want only GALED and GALEX", pairs)
+	}
+}
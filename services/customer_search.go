@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+//Customer search matching modes
+const (
+	matchPrefix   = "prefix"
+	matchContains = "contains"
+)
+
+//maxSearchTerms bounds the words of a query, each one adds a clause per searched column
+const maxSearchTerms = 5
+
+//minPhoneDigits is how many digits a word needs before it is also looked up in Phone
+const minPhoneDigits = 3
+
+//phoneDigits strips the usual separators from Phone so "555 2222" finds "(171) 555-2222"
+const phoneDigits = "REPLACE(REPLACE(REPLACE(REPLACE(REPLACE(IFNULL(Phone,''),' ',''),'-',''),'(',''),')',''),'.','')"
+
+//searchColumn weighs a match in one column, exact beats prefix beats contains
+type searchColumn struct {
+	name                    string
+	exact, prefix, contains int
+}
+
+var searchColumns = []searchColumn{
+	{"CompanyName", 100, 60, 20},
+	{"ContactName", 80, 50, 15},
+	{"City", 30, 20, 5},
+	{"Country", 30, 20, 5},
+}
+
+//phoneWeight scores a word found in the phone number
+const phoneWeight = 40
+
+//customerQuery is the WHERE, score and arguments built from a CustomerSearch
+type customerQuery struct {
+	where     []string
+	whereArgs []interface{}
+	score     []string
+	scoreArgs []interface{}
+}
+
+//buildCustomerSearch validates f and translates it to SQL, a non empty remark describes the invalid field
+func buildCustomerSearch(f cm.CustomerSearch) (q customerQuery, remark string) {
+	match := f.Match
+	if match == "" {
+		match = matchPrefix
+	}
+	if match != matchPrefix && match != matchContains {
+		return q, fmt.Sprintf("match must be %s or %s, got %q", matchPrefix, matchContains, f.Match)
+	}
+
+	terms := strings.Fields(f.Q)
+	if len(terms) > maxSearchTerms {
+		return q, fmt.Sprintf("q may hold at most %d words", maxSearchTerms)
+	}
+
+	//a phone number is searched as a whole, however it is spaced
+	if digits, ok := phoneQuery(f.Q, f.Country); ok {
+		q.where = append(q.where, phoneDigits+" LIKE ?")
+		q.whereArgs = append(q.whereArgs, "%"+digits+"%")
+		q.score = append(q.score, fmt.Sprintf("CASE WHEN %s LIKE ? THEN %d ELSE 0 END", phoneDigits, phoneWeight))
+		q.scoreArgs = append(q.scoreArgs, "%"+digits+"%")
+		terms = nil
+	}
+
+	for _, term := range terms {
+		escaped := escapeLike(term)
+
+		var any []string
+		for _, c := range searchColumns {
+			if match == matchPrefix {
+				//a word prefix: the start of the value or of any word in it
+				any = append(any, fmt.Sprintf("(%s LIKE ? OR %s LIKE ?)", c.name, c.name))
+				q.whereArgs = append(q.whereArgs, escaped+"%", "% "+escaped+"%")
+			} else {
+				any = append(any, c.name+" LIKE ?")
+				q.whereArgs = append(q.whereArgs, "%"+escaped+"%")
+			}
+
+			q.score = append(q.score, fmt.Sprintf("CASE WHEN %s = ? THEN %d WHEN %s LIKE ? THEN %d WHEN %s LIKE ? THEN %d ELSE 0 END",
+				c.name, c.exact, c.name, c.prefix, c.name, c.contains))
+			q.scoreArgs = append(q.scoreArgs, term, escaped+"%", "%"+escaped+"%")
+		}
+
+		//callers rarely know where their number starts, digits are matched anywhere in Phone
+		if digits := onlyDigits(term); len(digits) >= minPhoneDigits {
+			any = append(any, phoneDigits+" LIKE ?")
+			q.whereArgs = append(q.whereArgs, "%"+digits+"%")
+			q.score = append(q.score, fmt.Sprintf("CASE WHEN %s LIKE ? THEN %d ELSE 0 END", phoneDigits, phoneWeight))
+			q.scoreArgs = append(q.scoreArgs, "%"+digits+"%")
+		}
+
+		q.where = append(q.where, "("+strings.Join(any, " OR ")+")")
+	}
+
+	if f.Country != "" {
+		q.where = append(q.where, "Country = ?")
+		q.whereArgs = append(q.whereArgs, f.Country)
+	}
+	if f.City != "" {
+		q.where = append(q.where, "City = ?")
+		q.whereArgs = append(q.whereArgs, f.City)
+	}
+
+	return q, ""
+}
+
+//phoneQuery reads q as a phone number when it holds only digits and phone separators and
+//returns the digits to look for in Phone. Phones are stored in E.164, so q is normalized
+//the same way with the calling code of country, "030 0074321" in Germany becomes 49300074321.
+//Otherwise its international or trunk prefix is dropped and the rest is matched anywhere.
+func phoneQuery(q string, country string) (string, bool) {
+	p := phoneSeparators.Replace(strings.TrimSpace(q))
+	if digits := onlyDigits(p); len(digits) < minPhoneDigits || strings.TrimPrefix(p, "+") != digits {
+		return "", false
+	}
+
+	if rule, known := countryRules[strings.ToLower(country)]; known {
+		if phone, problem := normalizePhone(p, rule.callingCode); problem == "" {
+			return strings.TrimPrefix(phone, "+"), true
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(p, "+"):
+		return p[1:], true
+	case strings.HasPrefix(p, "00"):
+		return p[2:], true
+	}
+	return strings.TrimPrefix(p, "0"), true
+}
+
+//sql selects the customer columns and Score, best matches first
+func (q customerQuery) sql() (string, []interface{}) {
+	score := "0"
+	if len(q.score) > 0 {
+		score = strings.Join(q.score, "\n\t\t\t\t+ ")
+	}
+
+	sql := `SELECT
+				CustomerID,
+				IFNULL(CompanyName,'') CompanyName,
+				IFNULL(ContactName,'') ContactName,
+				IFNULL(ContactTitle,'') ContactTitle,
+				IFNULL(Address,'') Address,
+				IFNULL(City,'') City,
+				IFNULL(Country,'') Country,
+				IFNULL(Phone,'') Phone,
+				IFNULL(PostalCode,'') PostalCode,
+				` + score + ` AS Score
+			FROM customers`
+	if len(q.where) > 0 {
+		sql += " WHERE " + strings.Join(q.where, " AND ")
+	}
+	sql += " ORDER BY Score DESC, CompanyName, CustomerID"
+
+	return sql, append(append([]interface{}{}, q.scoreArgs...), q.whereArgs...)
+}
+
+//escapeLike makes term match literally inside a LIKE pattern
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
+
+func onlyDigits(term string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, term)
+}
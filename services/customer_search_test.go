@@ -0,0 +1,124 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+func TestBuildCustomerSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		search    cm.CustomerSearch
+		remark    string
+		where     []string
+		whereArgs []interface{}
+	}{
+		{
+			name:   "prefix by default",
+			search: cm.CustomerSearch{Q: "alf"},
+			where: []string{"((CompanyName LIKE ? OR CompanyName LIKE ?) OR (ContactName LIKE ? OR ContactName LIKE ?) OR " +
+				"(City LIKE ? OR City LIKE ?) OR (Country LIKE ? OR Country LIKE ?))"},
+			whereArgs: []interface{}{"alf%", "% alf%", "alf%", "% alf%", "alf%", "% alf%", "alf%", "% alf%"},
+		},
+		{
+			name:      "contains",
+			search:    cm.CustomerSearch{Q: "kiste", Match: matchContains},
+			where:     []string{"(CompanyName LIKE ? OR ContactName LIKE ? OR City LIKE ? OR Country LIKE ?)"},
+			whereArgs: []interface{}{"%kiste%", "%kiste%", "%kiste%", "%kiste%"},
+		},
+		{
+			name:   "every word has to match",
+			search: cm.CustomerSearch{Q: "ana trujillo", Match: matchContains},
+			where: []string{
+				"(CompanyName LIKE ? OR ContactName LIKE ? OR City LIKE ? OR Country LIKE ?)",
+				"(CompanyName LIKE ? OR ContactName LIKE ? OR City LIKE ? OR Country LIKE ?)",
+			},
+			whereArgs: []interface{}{"%ana%", "%ana%", "%ana%", "%ana%", "%trujillo%", "%trujillo%", "%trujillo%", "%trujillo%"},
+		},
+		{
+			name:      "like wildcards are literal",
+			search:    cm.CustomerSearch{Q: "50%_off", Match: matchContains},
+			where:     []string{"(CompanyName LIKE ? OR ContactName LIKE ? OR City LIKE ? OR Country LIKE ?)"},
+			whereArgs: []interface{}{`%50\%\_off%`, `%50\%\_off%`, `%50\%\_off%`, `%50\%\_off%`},
+		},
+		{
+			name:   "digits are looked up in Phone",
+			search: cm.CustomerSearch{Q: "berlin 555", Match: matchContains},
+			where: []string{
+				"(CompanyName LIKE ? OR ContactName LIKE ? OR City LIKE ? OR Country LIKE ?)",
+				"(CompanyName LIKE ? OR ContactName LIKE ? OR City LIKE ? OR Country LIKE ? OR " + phoneDigits + " LIKE ?)",
+			},
+			whereArgs: []interface{}{"%berlin%", "%berlin%", "%berlin%", "%berlin%", "%555%", "%555%", "%555%", "%555%", "%555%"},
+		},
+		{
+			name:      "filters",
+			search:    cm.CustomerSearch{CustomerFilter: cm.CustomerFilter{Country: "Germany", City: "Berlin"}},
+			where:     []string{"Country = ?", "City = ?"},
+			whereArgs: []interface{}{"Germany", "Berlin"},
+		},
+		{
+			name:   "unknown match",
+			search: cm.CustomerSearch{Q: "alf", Match: "fuzzy"},
+			remark: `match must be prefix or contains, got "fuzzy"`,
+		},
+		{
+			name:   "too many words",
+			search: cm.CustomerSearch{Q: "a b c d e f"},
+			remark: "q may hold at most 5 words",
+		},
+	}
+	for _, tt := range tests {
+		q, remark := buildCustomerSearch(tt.search)
+		if remark != tt.remark {
+			t.Errorf("%s: remark %q, want %q", tt.name, remark, tt.remark)
+			continue
+		}
+		if remark != "" {
+			continue
+		}
+		if !reflect.DeepEqual(q.where, tt.where) {
+			t.Errorf("%s: where\n%q\nwant\n%q", tt.name, q.where, tt.where)
+		}
+		if !reflect.DeepEqual(q.whereArgs, tt.whereArgs) {
+			t.Errorf("%s: where args %q, want %q", tt.name, q.whereArgs, tt.whereArgs)
+		}
+	}
+}
+
+func TestCustomerSearchRanking(t *testing.T) {
+	q, _ := buildCustomerSearch(cm.CustomerSearch{Q: "alfreds"})
+
+	if len(q.score) != len(searchColumns) || len(q.scoreArgs) != 3*len(searchColumns) {
+		t.Fatalf("%d score terms with %d args for %d columns", len(q.score), len(q.scoreArgs), len(searchColumns))
+	}
+	for i, c := range searchColumns {
+		want := "CASE WHEN " + c.name + " = ? THEN "
+		if !strings.HasPrefix(q.score[i], want) {
+			t.Errorf("score %d is %q, want it to start with %q", i, q.score[i], want)
+		}
+		if args := q.scoreArgs[3*i : 3*i+3]; !reflect.DeepEqual(args, []interface{}{"alfreds", "alfreds%", "%alfreds%"}) {
+			t.Errorf("%s score args %q", c.name, args)
+		}
+		if !(c.exact > c.prefix && c.prefix > c.contains && c.contains > 0) {
+			t.Errorf("%s weights %d, %d, %d do not rank exact over prefix over contains", c.name, c.exact, c.prefix, c.contains)
+		}
+	}
+
+	//a company name match outranks a match of any other single column
+	for _, c := range searchColumns[1:] {
+		if c.exact >= searchColumns[0].exact || c.prefix >= searchColumns[0].prefix {
+			t.Errorf("%s outranks CompanyName", c.name)
+		}
+	}
+
+	sql, args := q.sql()
+	if !strings.HasSuffix(sql, "ORDER BY Score DESC, CompanyName, CustomerID") {
+		t.Errorf("results are not ordered by score: %s", sql)
+	}
+	if len(args) != len(q.scoreArgs)+len(q.whereArgs) || args[0] != "alfreds" {
+		t.Errorf("score args do not come first: %q", args)
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+//customerColumnSize is the width of each customers column, longer values would be cut by MySQL
+var customerColumnSize = []struct {
+	field string
+	size  int
+}{
+	{"CustomerID", 5},
+	{"CompanyName", 40},
+	{"ContactName", 30},
+	{"ContactTitle", 30},
+	{"Address", 60},
+	{"City", 15},
+	{"Country", 15},
+	{"Phone", 24},
+	{"PostalCode", 10},
+}
+
+//countryRule is what is known about the phones and postal codes of one country
+type countryRule struct {
+	callingCode string
+	postalCode  *regexp.Regexp
+	example     string
+}
+
+//countryRules is keyed by the lower case country name as stored in customers.Country,
+//countries missing here get no postal code check and need international phone numbers
+var countryRules = map[string]countryRule{
+	"argentina":      {"54", regexp.MustCompile(`^[A-Z]?\d{4}([A-Z]{3})?$`), "1010"},
+	"austria":        {"43", regexp.MustCompile(`^\d{4}$`), "5020"},
+	"belgium":        {"32", regexp.MustCompile(`^(B-)?\d{4}$`), "1180"},
+	"brazil":         {"55", regexp.MustCompile(`^\d{5}-?\d{3}$`), "05432-043"},
+	"canada":         {"1", regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`), "T2F 8M4"},
+	"denmark":        {"45", regexp.MustCompile(`^\d{4}$`), "1734"},
+	"finland":        {"358", regexp.MustCompile(`^\d{5}$`), "21240"},
+	"france":         {"33", regexp.MustCompile(`^\d{5}$`), "51100"},
+	"germany":        {"49", regexp.MustCompile(`^\d{5}$`), "12209"},
+	"indonesia":      {"62", regexp.MustCompile(`^\d{5}$`), "10110"},
+	"ireland":        {"353", regexp.MustCompile(`^[A-Z]\d[\dW] ?[A-Z\d]{4}$`), "D02 X285"},
+	"italy":          {"39", regexp.MustCompile(`^\d{5}$`), "42100"},
+	"mexico":         {"52", regexp.MustCompile(`^\d{5}$`), "05021"},
+	"norway":         {"47", regexp.MustCompile(`^\d{4}$`), "4110"},
+	"poland":         {"48", regexp.MustCompile(`^\d{2}-\d{3}$`), "01-012"},
+	"portugal":       {"351", regexp.MustCompile(`^\d{4}(-\d{3})?$`), "1675-001"},
+	"spain":          {"34", regexp.MustCompile(`^\d{5}$`), "28023"},
+	"sweden":         {"46", regexp.MustCompile(`^(S-)?\d{3} ?\d{2}$`), "958 22"},
+	"switzerland":    {"41", regexp.MustCompile(`^\d{4}$`), "1203"},
+	"uk":             {"44", regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`), "EC2 5NT"},
+	"united kingdom": {"44", regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`), "EC2 5NT"},
+	"usa":            {"1", regexp.MustCompile(`^\d{5}(-\d{4})?$`), "97219"},
+	"united states":  {"1", regexp.MustCompile(`^\d{5}(-\d{4})?$`), "97219"},
+	"venezuela":      {"58", regexp.MustCompile(`^\d{4}$`), "5022"},
+}
+
+//e164 is a normalized phone number, a plus and at most 15 digits
+var e164 = regexp.MustCompile(`^\+[1-9]\d{6,14}$`)
+
+//phoneSeparators are dropped from phone numbers before they are normalized
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+
+//normalizeCustomer trims c, rewrites its phone to E.164 and its postal code to upper case,
+//and returns every field that is still invalid
+func normalizeCustomer(c cm.Customers) (cm.Customers, []cm.FieldError) {
+	var errs []cm.FieldError
+
+	for _, f := range []*string{&c.CustomerID, &c.CompanyName, &c.ContactName, &c.ContactTitle,
+		&c.Address, &c.City, &c.Country, &c.Phone, &c.PostalCode} {
+		*f = strings.TrimSpace(*f)
+	}
+	c.PostalCode = strings.ToUpper(c.PostalCode)
+
+	if c.CustomerID == "" {
+		errs = append(errs, cm.FieldError{Field: "CustomerID", Problem: "is required"})
+	}
+	if c.CompanyName == "" {
+		errs = append(errs, cm.FieldError{Field: "CompanyName", Problem: "is required"})
+	}
+
+	rule, known := countryRules[strings.ToLower(c.Country)]
+
+	if c.Phone != "" {
+		phone, problem := normalizePhone(c.Phone, rule.callingCode)
+		if problem != "" {
+			errs = append(errs, cm.FieldError{Field: "Phone", Problem: problem})
+		} else {
+			c.Phone = phone
+		}
+	}
+
+	if c.PostalCode != "" && known && !rule.postalCode.MatchString(c.PostalCode) {
+		errs = append(errs, cm.FieldError{Field: "PostalCode",
+			Problem: fmt.Sprintf("is not a valid postal code in %s, e.g. %s", c.Country, rule.example)})
+	}
+
+	values := map[string]string{
+		"CustomerID":   c.CustomerID,
+		"CompanyName":  c.CompanyName,
+		"ContactName":  c.ContactName,
+		"ContactTitle": c.ContactTitle,
+		"Address":      c.Address,
+		"City":         c.City,
+		"Country":      c.Country,
+		"Phone":        c.Phone,
+		"PostalCode":   c.PostalCode,
+	}
+	for _, col := range customerColumnSize {
+		if n := utf8.RuneCountInString(values[col.field]); n > col.size {
+			errs = append(errs, cm.FieldError{Field: col.field,
+				Problem: fmt.Sprintf("is %d characters long, at most %d are allowed", n, col.size)})
+		}
+	}
+
+	return c, errs
+}
+
+//normalizePhone rewrites phone to E.164, national numbers get callingCode in front of them
+//instead of their trunk prefix 0
+func normalizePhone(phone string, callingCode string) (string, string) {
+	p := phoneSeparators.Replace(phone)
+
+	switch {
+	case strings.HasPrefix(p, "+"):
+	case strings.HasPrefix(p, "00"):
+		p = "+" + p[2:]
+	case callingCode == "":
+		return "", "must be in international format, e.g. +6221555123"
+	case callingCode == "1":
+		p = "+1" + strings.TrimPrefix(p, "1")
+	default:
+		p = "+" + callingCode + strings.TrimPrefix(p, "0")
+	}
+
+	if !e164.MatchString(p) {
+		return "", "is not a valid phone number, use digits only with an optional leading +"
+	}
+	return p, ""
+}
+
+//fieldRemark joins errs into one line for the remark of a response
+func fieldRemark(errs []cm.FieldError) string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Field + " " + e.Problem
+	}
+	return strings.Join(msgs, "; ")
+}
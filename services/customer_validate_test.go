@@ -0,0 +1,178 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name        string
+		phone       string
+		callingCode string
+		want        string
+		problem     bool
+	}{
+		{"international", "+62 21 555 123", "", "+6221555123", false},
+		{"international wins over country", "+49 30 1234567", "62", "+49301234567", false},
+		{"00 prefix", "0049 (30) 1234567", "", "+49301234567", false},
+		{"00 prefix with country", "0033-1-42-68-53-00", "49", "+33142685300", false},
+		{"trunk 0 dropped", "030-0074321", "49", "+49300074321", false},
+		{"national without trunk 0", "21 555 123", "62", "+6221555123", false},
+		{"separators", "(5) 555-4729", "52", "+5255554729", false},
+		{"dots and slashes", "0621.08/460", "49", "+4962108460", false},
+		{"NANP national", "(503) 555-7555", "1", "+15035557555", false},
+		{"NANP with leading 1", "1 503 555 7555", "1", "+15035557555", false},
+		{"unknown country needs international", "030-0074321", "", "", true},
+		{"letters", "+62 21 CALL ME", "62", "", true},
+		{"too short", "+62 12", "", "", true},
+		{"too long", "+1234567890123456", "", "", true},
+		{"zero after plus", "+0621555123", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, problem := normalizePhone(tt.phone, tt.callingCode)
+			if got != tt.want || (problem != "") != tt.problem {
+				t.Fatalf("normalizePhone(%q, %q) = %q, %q, want %q with problem %v",
+					tt.phone, tt.callingCode, got, problem, tt.want, tt.problem)
+			}
+		})
+	}
+}
+
+func TestCountryPostalCodes(t *testing.T) {
+	for country, rule := range countryRules {
+		if !rule.postalCode.MatchString(rule.example) {
+			t.Errorf("the example postal code %q of %s does not match its rule", rule.example, country)
+		}
+	}
+
+	tests := []struct {
+		country string
+		postal  string
+		valid   bool
+	}{
+		{"germany", "12209", true},
+		{"germany", "1220", false},
+		{"austria", "5020", true},
+		{"austria", "50200", false},
+		{"belgium", "B-1180", true},
+		{"brazil", "05432043", true},
+		{"brazil", "05432-04", false},
+		{"canada", "T2F8M4", true},
+		{"canada", "T2F-8M4", false},
+		{"ireland", "D6W XY12", true},
+		{"poland", "01-012", true},
+		{"poland", "01012", false},
+		{"portugal", "1675", true},
+		{"sweden", "S-958 22", true},
+		{"sweden", "95822", true},
+		{"uk", "WA1 1DP", true},
+		{"uk", "SW1A 1AA", true},
+		{"uk", "12345", false},
+		{"usa", "97219-1234", true},
+		{"usa", "9721", false},
+		{"argentina", "C1010AAA", true},
+	}
+	for _, tt := range tests {
+		if got := countryRules[tt.country].postalCode.MatchString(tt.postal); got != tt.valid {
+			t.Errorf("postal code %q in %s: valid = %v, want %v", tt.postal, tt.country, got, tt.valid)
+		}
+	}
+}
+
+func TestNormalizeCustomer(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     cm.Customers
+		want   cm.Customers
+		fields []string
+	}{
+		{
+			name: "trimmed and normalized",
+			in: cm.Customers{CustomerID: " ALFKI ", CompanyName: " Alfreds Futterkiste ", Country: "Germany",
+				Phone: "030-0074321", PostalCode: " 12209 "},
+			want: cm.Customers{CustomerID: "ALFKI", CompanyName: "Alfreds Futterkiste", Country: "Germany",
+				Phone: "+49300074321", PostalCode: "12209"},
+		},
+		{
+			name: "postal code upper cased before the check",
+			in:   cm.Customers{CustomerID: "BOTTM", CompanyName: "Bottom-Dollar", Country: "Canada", PostalCode: "t2f 8m4"},
+			want: cm.Customers{CustomerID: "BOTTM", CompanyName: "Bottom-Dollar", Country: "Canada", PostalCode: "T2F 8M4"},
+		},
+		{
+			name: "country matched case insensitively",
+			in:   cm.Customers{CustomerID: "GREAL", CompanyName: "Great Lakes", Country: "USA", Phone: "(503) 555-7555"},
+			want: cm.Customers{CustomerID: "GREAL", CompanyName: "Great Lakes", Country: "USA", Phone: "+15035557555"},
+		},
+		{
+			name: "unknown country skips the postal check",
+			in:   cm.Customers{CustomerID: "NOWHR", CompanyName: "Nowhere", Country: "Atlantis", PostalCode: "??", Phone: "+62 21 555 123"},
+			want: cm.Customers{CustomerID: "NOWHR", CompanyName: "Nowhere", Country: "Atlantis", PostalCode: "??", Phone: "+6221555123"},
+		},
+		{
+			name:   "every problem reported",
+			in:     cm.Customers{Country: "France", Phone: "abc", PostalCode: "5110", City: strings.Repeat("x", 16)},
+			want:   cm.Customers{Country: "France", Phone: "abc", PostalCode: "5110", City: strings.Repeat("x", 16)},
+			fields: []string{"CustomerID", "CompanyName", "Phone", "PostalCode", "City"},
+		},
+		{
+			name:   "unknown country needs an international phone",
+			in:     cm.Customers{CustomerID: "NOWHR", CompanyName: "Nowhere", Phone: "21 555 123"},
+			want:   cm.Customers{CustomerID: "NOWHR", CompanyName: "Nowhere", Phone: "21 555 123"},
+			fields: []string{"Phone"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := normalizeCustomer(tt.in)
+			if got != tt.want {
+				t.Errorf("normalized to %+v, want %+v", got, tt.want)
+			}
+			var fields []string
+			for _, e := range errs {
+				fields = append(fields, e.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("invalid fields %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestPhoneQuery(t *testing.T) {
+	tests := []struct {
+		q, country string
+		want       string
+		ok         bool
+	}{
+		{"030 0074321", "Germany", "49300074321", true},
+		{"030-0074321", "germany", "49300074321", true},
+		{"+49 30 0074321", "Germany", "49300074321", true},
+		{"0049 30 0074321", "", "49300074321", true},
+		{"030 0074321", "", "300074321", true},
+		{"(171) 555-2222", "", "1715552222", true},
+		{"555", "Germany", "555", true},
+		{"55", "", "", false},
+		{"berlin 555", "", "", false},
+		{"12209 Berlin", "Germany", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := phoneQuery(tt.q, tt.country)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("phoneQuery(%q, %q) = %q, %v, want %q, %v", tt.q, tt.country, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	//the stored E.164 phone holds the digits of the query
+	q, _ := buildCustomerSearch(cm.CustomerSearch{Q: "030 0074321", CustomerFilter: cm.CustomerFilter{Country: "Germany"}})
+	if want := []interface{}{"%49300074321%", "Germany"}; !reflect.DeepEqual(q.whereArgs, want) {
+		t.Errorf("where args %q, want %q", q.whereArgs, want)
+	}
+	if !strings.Contains("+49300074321", strings.Trim(q.whereArgs[0].(string), "%")) {
+		t.Errorf("%s does not match +49300074321", q.whereArgs[0])
+	}
+}
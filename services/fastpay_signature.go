@@ -0,0 +1,78 @@
+package services
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//fastpaySignature is sha1(md5(user + password + fields)), both digests as lower case hex
+func fastpaySignature(user string, password string, fields ...string) string {
+	m := md5.Sum([]byte(user + password + strings.Join(fields, "")))
+	s := sha1.Sum([]byte(hex.EncodeToString(m[:])))
+	return hex.EncodeToString(s[:])
+}
+
+//signedFields are the fields of req its signature covers, in the order they are signed.
+//Signing the credentials alone gave every request of a merchant the same signature, the
+//timestamp keeps a captured request from being replayed once it is older than maxClockSkew.
+func signedFields(req cm.FastPayRequest) []string {
+	return []string{req.MerchantID, req.Merchant, req.Request, req.Timestamp}
+}
+
+//verifyFastPay recomputes the signature of req with the credentials of its merchant_id and
+//checks its timestamp is within maxClockSkew of now, a non empty code is the response_code
+//req has to be rejected with
+func verifyFastPay(merchants map[string]cm.FastPayMerchant, maxClockSkew time.Duration, now time.Time, req cm.FastPayRequest) (code string, desc string) {
+	if req.MerchantID == "" || req.Timestamp == "" || req.Signature == "" {
+		return cm.FastPayInvalidRequest, "merchant_id, timestamp and signature are required"
+	}
+	signedAt, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return cm.FastPayInvalidRequest, "timestamp must be in unix seconds"
+	}
+
+	m, ok := merchants[req.MerchantID]
+	if !ok {
+		//compared anyway, an unknown merchant answers like a wrong signature and just as fast
+		m = cm.FastPayMerchant{User: req.MerchantID}
+	}
+
+	want := fastpaySignature(m.User, m.Password, signedFields(req)...)
+	got := strings.ToLower(req.Signature)
+	if subtle.ConstantTimeCompare([]byte(want), []byte(got)) != 1 || !ok {
+		return cm.FastPayInvalidSignature, "Invalid signature"
+	}
+
+	//checked once the signature holds, so the timestamp cannot have been moved
+	if age := now.Sub(time.Unix(signedAt, 0)); age > maxClockSkew || age < -maxClockSkew {
+		return cm.FastPayInvalidRequest, fmt.Sprintf("timestamp is more than %s away from the server time", maxClockSkew)
+	}
+
+	return "", ""
+}
+
+//rejectFastPay answers req when its signature does not verify. It runs before any query,
+//so an unsigned request learns nothing about the merchant.
+func (s PaymentService) rejectFastPay(req cm.FastPayRequest) (res cm.FastPayResponse, rejected bool) {
+	code, desc := verifyFastPay(s.conf.FastPay.Merchants, s.conf.FastPay.MaxClockSkew, time.Now(), req)
+	if code == "" {
+		return res, false
+	}
+
+	log.WithField("merchant_id", req.MerchantID).Warn("Rejected FastPay request, " + desc)
+	res.Merchant = req.Merchant
+	res.MerchantID = req.MerchantID
+	res.ResponseCode = code
+	res.ResponseDesc = desc
+	return res, true
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+//testMerchants are made up credentials, the vectors below were computed with
+//printf '%s' "$(printf '%s' <user><password><fields> | md5sum | cut -d' ' -f1)" | sha1sum
+var testMerchants = map[string]cm.FastPayMerchant{
+	"32000": {User: "bot32000", Password: "s3cret"},
+}
+
+//signedAt is the timestamp of the signed inquiry, 2026-10-18 00:00:00 UTC
+const signedAt = "1792281600"
+
+const signedInquiry = "7ba745ad89ec6852d7bee6b529b40068e1867d58"
+
+func TestFastPaySignature(t *testing.T) {
+	tests := []struct {
+		user, password string
+		fields         []string
+		want           string
+	}{
+		{"bot32000", "s3cret", []string{"32000", "FastPay", "Daftar Payment Channel", signedAt}, signedInquiry},
+		{"bot32000", "s3cret", nil, "943d91ae8776089ea16bb05c4f4791fa47f349db"},
+	}
+	for _, tt := range tests {
+		if got := fastpaySignature(tt.user, tt.password, tt.fields...); got != tt.want {
+			t.Errorf("fastpaySignature(%q, %q, %q) = %s, want %s", tt.user, tt.password, tt.fields, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyFastPay(t *testing.T) {
+	inquiry := cm.FastPayRequest{MerchantID: "32000", Merchant: "FastPay", Request: "Daftar Payment Channel",
+		Timestamp: signedAt, Signature: signedInquiry}
+	now := time.Unix(1792281600, 0).Add(time.Minute)
+	resign := func(r *cm.FastPayRequest) { r.Signature = fastpaySignature("bot32000", "s3cret", signedFields(*r)...) }
+
+	with := func(change func(r *cm.FastPayRequest)) cm.FastPayRequest {
+		r := inquiry
+		change(&r)
+		return r
+	}
+
+	tests := []struct {
+		name string
+		req  cm.FastPayRequest
+		code string
+	}{
+		{"valid", inquiry, ""},
+		{"upper case hex", with(func(r *cm.FastPayRequest) { r.Signature = strings.ToUpper(signedInquiry) }), ""},
+		{"missing signature", with(func(r *cm.FastPayRequest) { r.Signature = "" }), cm.FastPayInvalidRequest},
+		{"missing merchant_id", with(func(r *cm.FastPayRequest) { r.MerchantID = "" }), cm.FastPayInvalidRequest},
+		{"missing timestamp", with(func(r *cm.FastPayRequest) { r.Timestamp = ""; resign(r) }), cm.FastPayInvalidRequest},
+		{"timestamp not in seconds", with(func(r *cm.FastPayRequest) { r.Timestamp = "2026-10-18T00:00:00Z"; resign(r) }), cm.FastPayInvalidRequest},
+		{"other timestamp", with(func(r *cm.FastPayRequest) { r.Timestamp = "1792281660" }), cm.FastPayInvalidSignature},
+		{"replayed later", with(func(r *cm.FastPayRequest) { r.Timestamp = "1792281000"; resign(r) }), cm.FastPayInvalidRequest},
+		{"from the future", with(func(r *cm.FastPayRequest) { r.Timestamp = "1792282200"; resign(r) }), cm.FastPayInvalidRequest},
+		{"at the edge of the window", with(func(r *cm.FastPayRequest) { r.Timestamp = "1792281360"; resign(r) }), ""},
+		{"unknown merchant", with(func(r *cm.FastPayRequest) { r.MerchantID = "99999" }), cm.FastPayInvalidSignature},
+		{"other merchant name", with(func(r *cm.FastPayRequest) { r.Merchant = "Other" }), cm.FastPayInvalidSignature},
+		{"other request", with(func(r *cm.FastPayRequest) { r.Request = "Cek Tagihan" }), cm.FastPayInvalidSignature},
+		{"credentials only", with(func(r *cm.FastPayRequest) { r.Signature = fastpaySignature("bot32000", "s3cret") }), cm.FastPayInvalidSignature},
+		{"wrong password", with(func(r *cm.FastPayRequest) {
+			r.Signature = fastpaySignature("bot32000", "guess", signedFields(*r)...)
+		}), cm.FastPayInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, desc := verifyFastPay(testMerchants, 5*time.Minute, now, tt.req)
+			if code != tt.code {
+				t.Fatalf("code %q (%s), want %q", code, desc, tt.code)
+			}
+			if (desc == "") != (code == "") {
+				t.Fatalf("code %q came with desc %q", code, desc)
+			}
+		})
+	}
+}
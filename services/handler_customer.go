@@ -2,34 +2,14 @@ package services
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
-
-	_ "github.com/go-sql-driver/mysql"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
 )
 
-var db *sql.DB
-var err error
-
-func (PaymentService) CustomerHandler(ctx context.Context, req cm.Customers) (res cm.Customers) {
+func (s PaymentService) CustomerHandler(ctx context.Context, req cm.Customers) (res cm.Customers) {
 	defer panicRecovery()
 
-	host := cm.Config.Connection.Host
-	port := cm.Config.Connection.Port
-	user := cm.Config.Connection.User
-	pass := cm.Config.Connection.Password
-	data := cm.Config.Connection.Database
-
-	var mySQL = fmt.Sprintf("%v:%v@tcp(%v:%v)/%v", user, pass, host, port, data)
-
-	db, err = sql.Open("mysql", mySQL)
-
-	if err != nil {
-		panic(err.Error())
-	}
-
 	res.CustomerID = req.CustomerID
 
 	var customer cm.Customers
@@ -46,14 +26,14 @@ func (PaymentService) CustomerHandler(ctx context.Context, req cm.Customers) (re
 				IFNULL(PostalCode,'') PostalCode
 			FROM customers WHERE CustomerID = ?`
 
-	result, err := db.Query(sql, req.CustomerID)
-
-	defer result.Close()
+	result, err := s.db(AreaCustomers).Query(sql, req.CustomerID)
 
 	if err != nil {
 		panic(err.Error())
 	}
 
+	defer result.Close()
+
 	for result.Next() {
 
 		err := result.Scan(&customer.CustomerID, &customer.CompanyName, &customer.ContactName,
@@ -70,3 +50,32 @@ func (PaymentService) CustomerHandler(ctx context.Context, req cm.Customers) (re
 
 	return
 }
+
+//findCustomer reads the customer id, found is false when there is none
+func findCustomer(db *database.DbConnection, id string) (c cm.Customers, found bool, err error) {
+	result, err := db.Query(`SELECT
+				CustomerID,
+				IFNULL(CompanyName,'') CompanyName,
+				IFNULL(ContactName,'') ContactName,
+				IFNULL(ContactTitle,'') ContactTitle,
+				IFNULL(Address,'') Address,
+				IFNULL(City,'') City,
+				IFNULL(Country,'') Country,
+				IFNULL(Phone,'') Phone,
+				IFNULL(PostalCode,'') PostalCode
+			FROM customers WHERE CustomerID = ?`, id)
+	if err != nil {
+		return c, false, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		if err := result.Scan(&c.CustomerID, &c.CompanyName, &c.ContactName,
+			&c.ContactTitle, &c.Address, &c.City, &c.Country,
+			&c.Phone, &c.PostalCode); err != nil {
+			return c, false, err
+		}
+		found = true
+	}
+	return c, found, result.Err()
+}
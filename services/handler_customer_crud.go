@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//GetCustomerHandler answers the customer req.CustomerID, CodeNotFound when there is none
+func (s PaymentService) GetCustomerHandler(ctx context.Context, req cm.Customers) (res cm.CustomerResult) {
+
+	defer panicRecovery()
+
+	c, found, err := findCustomer(s.db(AreaCustomers), req.CustomerID)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to load customer")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to load customer"
+		return
+	}
+	if !found {
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("CustomerID %s does not exist", req.CustomerID)
+		return
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Customer = &c
+	return
+}
+
+//CreateCustomerHandler inserts req, CodeConflict when its CustomerID is taken
+func (s PaymentService) CreateCustomerHandler(ctx context.Context, req cm.Customers) (res cm.CustomerResult) {
+
+	defer panicRecovery()
+
+	req, errs := normalizeCustomer(req)
+	if len(errs) > 0 {
+		res.Code = cm.CodeInvalid
+		res.Remark = fieldRemark(errs)
+		res.Errors = errs
+		return
+	}
+
+	db := s.db(AreaCustomers)
+
+	var taken int
+	result, err := db.Query("SELECT COUNT(*) FROM customers WHERE CustomerID = ?", req.CustomerID)
+	if err == nil {
+		for result.Next() {
+			err = result.Scan(&taken)
+		}
+		result.Close()
+	}
+	if err != nil {
+		log.WithField("error", err).Error("Unable to check customer")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to create customer"
+		return
+	}
+	if taken > 0 {
+		res.Code = cm.CodeConflict
+		res.Remark = fmt.Sprintf("CustomerID %s already exists", req.CustomerID)
+		return
+	}
+
+	//a concurrent insert of the same id still ends here as a duplicate key
+	_, err = db.Exec(`INSERT INTO customers (CustomerID, CompanyName, ContactName, ContactTitle, Address, City, Country, Phone, PostalCode)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		req.CustomerID, req.CompanyName, nullable(req.ContactName), nullable(req.ContactTitle), nullable(req.Address),
+		nullable(req.City), nullable(req.Country), nullable(req.Phone), nullable(req.PostalCode))
+	if isDuplicateKey(err) {
+		res.Code = cm.CodeConflict
+		res.Remark = fmt.Sprintf("CustomerID %s already exists", req.CustomerID)
+		return
+	}
+	if err != nil {
+		log.WithField("error", err).Error("Unable to create customer")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to create customer"
+		return
+	}
+
+	return s.GetCustomerHandler(ctx, req)
+}
+
+//UpdateCustomerHandler replaces every field of the customer req.CustomerID, empty fields are cleared
+func (s PaymentService) UpdateCustomerHandler(ctx context.Context, req cm.Customers) (res cm.CustomerResult) {
+
+	defer panicRecovery()
+
+	return s.PatchCustomerHandler(ctx, cm.CustomerPatch{
+		CustomerID:   req.CustomerID,
+		CompanyName:  &req.CompanyName,
+		ContactName:  &req.ContactName,
+		ContactTitle: &req.ContactTitle,
+		Address:      &req.Address,
+		City:         &req.City,
+		Country:      &req.Country,
+		Phone:        &req.Phone,
+		PostalCode:   &req.PostalCode,
+	})
+}
+
+//PatchCustomerHandler changes the fields set in req and keeps the others. The result is
+//validated as a whole, but only problems with the changed fields are reported
+func (s PaymentService) PatchCustomerHandler(ctx context.Context, req cm.CustomerPatch) (res cm.CustomerResult) {
+
+	defer panicRecovery()
+
+	patch := map[string]*string{
+		"CompanyName":  req.CompanyName,
+		"ContactName":  req.ContactName,
+		"ContactTitle": req.ContactTitle,
+		"Address":      req.Address,
+		"City":         req.City,
+		"Country":      req.Country,
+		"Phone":        req.Phone,
+		"PostalCode":   req.PostalCode,
+	}
+	changed := map[string]bool{}
+	for field, value := range patch {
+		if value != nil {
+			changed[field] = true
+		}
+	}
+	if len(changed) == 0 {
+		res.Code = cm.CodeInvalid
+		res.Remark = "nothing to change, send at least one field"
+		return
+	}
+
+	db := s.db(AreaCustomers)
+
+	//MySQL reports 0 affected rows when nothing changed, so existence is checked first
+	current, found, err := findCustomer(db, req.CustomerID)
+	if err == nil && found {
+		//the phone and postal code rules depend on the country
+		if changed["Country"] {
+			changed["Phone"], changed["PostalCode"] = true, true
+		}
+
+		c := current
+		columns := []struct {
+			name  string
+			value *string
+		}{
+			{"CompanyName", &c.CompanyName},
+			{"ContactName", &c.ContactName},
+			{"ContactTitle", &c.ContactTitle},
+			{"Address", &c.Address},
+			{"City", &c.City},
+			{"Country", &c.Country},
+			{"Phone", &c.Phone},
+			{"PostalCode", &c.PostalCode},
+		}
+		for _, col := range columns {
+			if v := patch[col.name]; v != nil {
+				*col.value = *v
+			}
+		}
+
+		var errs []cm.FieldError
+		c, all := normalizeCustomer(c)
+		for _, e := range all {
+			if changed[e.Field] {
+				errs = append(errs, e)
+			}
+		}
+		if len(errs) > 0 {
+			res.Code = cm.CodeInvalid
+			res.Remark = fieldRemark(errs)
+			res.Errors = errs
+			return
+		}
+
+		var set []string
+		var args []interface{}
+		for _, col := range columns {
+			if changed[col.name] {
+				set = append(set, col.name+" = ?")
+				args = append(args, nullable(*col.value))
+			}
+		}
+		_, err = db.Exec("UPDATE customers SET "+strings.Join(set, ", ")+" WHERE CustomerID = ?", append(args, req.CustomerID)...)
+	}
+	if err != nil {
+		log.WithField("error", err).Error("Unable to update customer")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to update customer"
+		return
+	}
+	if !found {
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("CustomerID %s does not exist", req.CustomerID)
+		return
+	}
+
+	return s.GetCustomerHandler(ctx, cm.Customers{CustomerID: req.CustomerID})
+}
+
+//DeleteCustomerHandler removes the customer req.CustomerID, CodeConflict while orders still refer to it
+func (s PaymentService) DeleteCustomerHandler(ctx context.Context, req cm.Customers) (res cm.CustomerResult) {
+
+	defer panicRecovery()
+
+	tx := s.db(AreaCustomers).WithTx()
+	if err := tx.Begin(); err != nil {
+		log.WithField("error", err).Error("Unable to begin transaction")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to delete customer"
+		return
+	}
+	//a no-op once committed, releases the customer lock on any early return or panic
+	defer tx.Rollback()
+
+	orders, deleted, err := deleteCustomer(tx, req.CustomerID)
+	if err != nil || orders > 0 || deleted == 0 {
+		switch {
+		case err != nil:
+			log.WithField("error", err).Error("Unable to delete customer")
+			res.Code = cm.CodeInternal
+			res.Remark = "Unable to delete customer"
+		case orders > 0:
+			res.Code = cm.CodeConflict
+			res.Remark = fmt.Sprintf("CustomerID %s still has %d orders", req.CustomerID, orders)
+		default:
+			res.Code = cm.CodeNotFound
+			res.Remark = fmt.Sprintf("CustomerID %s does not exist", req.CustomerID)
+		}
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithField("error", err).Error("Unable to commit customer")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to delete customer"
+		return
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	return
+}
+
+//deleteCustomer deletes id unless orders refer to it. The customer row is locked first:
+//insertOrder share locks it, so an order being created for id makes the delete wait and is
+//counted, and an order created after the lock waits and then finds no customer
+func deleteCustomer(tx *database.DbConnection, id string) (orders int, deleted int64, err error) {
+	result, err := tx.QueryTx("SELECT CustomerID FROM customers WHERE CustomerID = ? FOR UPDATE", id)
+	if err != nil {
+		return 0, 0, err
+	}
+	result.Close()
+
+	result, err = tx.QueryTx("SELECT COUNT(*) FROM orders WHERE CustomerID = ?", id)
+	if err != nil {
+		return 0, 0, err
+	}
+	for result.Next() {
+		if err := result.Scan(&orders); err != nil {
+			result.Close()
+			return 0, 0, err
+		}
+	}
+	result.Close()
+	if orders > 0 {
+		return orders, 0, nil
+	}
+
+	deleted, err = tx.ExecTx("DELETE FROM customers WHERE CustomerID = ?", id)
+	return 0, deleted, err
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestCreateCustomerHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    cm.Customers
+		expect func(sqlmock.Sqlmock)
+		code   int
+	}{
+		{
+			name: "created",
+			req:  cm.Customers{CustomerID: "NEWCO", CompanyName: "New Co", City: "Berlin", Country: "Germany"},
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM customers`).WithArgs("NEWCO").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectExec("INSERT INTO customers").WithArgs("NEWCO", "New Co", nil, nil, nil, "Berlin", "Germany", nil, nil).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("NEWCO").
+					WillReturnRows(customerRow(cm.Customers{CustomerID: "NEWCO", CompanyName: "New Co", City: "Berlin", Country: "Germany"}))
+			},
+			code: cm.CodeSuccess,
+		},
+		{
+			name: "id taken",
+			req:  alfki,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM customers`).WithArgs("ALFKI").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+			},
+			code: cm.CodeConflict,
+		},
+		{
+			name: "id taken concurrently",
+			req:  alfki,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM customers`).WithArgs("ALFKI").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectExec("INSERT INTO customers").WillReturnError(&mysql.MySQLError{Number: erDupEntry})
+			},
+			code: cm.CodeConflict,
+		},
+		{
+			name:   "no company name",
+			req:    cm.Customers{CustomerID: "NEWCO"},
+			expect: func(sqlmock.Sqlmock) {},
+			code:   cm.CodeInvalid,
+		},
+		{
+			name: "database down",
+			req:  alfki,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM customers`).WillReturnError(errors.New("connection refused"))
+			},
+			code: cm.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		svc, mock := mockService(t)
+		tt.expect(mock)
+
+		if res := svc.CreateCustomerHandler(context.Background(), tt.req); res.Code != tt.code {
+			t.Errorf("%s: code %d %q, want %d", tt.name, res.Code, res.Remark, tt.code)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestUpdateCustomerHandler(t *testing.T) {
+	svc, mock := mockService(t)
+
+	//every field is written, the empty ones as NULL
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("ALFKI").WillReturnRows(customerRow(alfki))
+	mock.ExpectExec(`UPDATE customers SET CompanyName = \?, ContactName = \?, ContactTitle = \?, Address = \?, City = \?, Country = \?, Phone = \?, PostalCode = \? WHERE CustomerID = \?`).
+		WithArgs("Alfreds Futterkiste", nil, nil, nil, "Berlin", "Germany", "+49300074321", "12209", "ALFKI").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("ALFKI").WillReturnRows(customerRow(alfki))
+
+	res := svc.UpdateCustomerHandler(context.Background(), cm.Customers{CustomerID: "ALFKI", CompanyName: "Alfreds Futterkiste",
+		City: "Berlin", Country: "Germany", Phone: "+49300074321", PostalCode: "12209"})
+	if res.Code != cm.CodeSuccess || res.Customer == nil || res.Customer.CustomerID != "ALFKI" {
+		t.Errorf("update: %+v", res)
+	}
+
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("NOPE").WillReturnRows(sqlmock.NewRows(customerColumns))
+	if res := svc.UpdateCustomerHandler(context.Background(), cm.Customers{CustomerID: "NOPE", CompanyName: "Nope"}); res.Code != cm.CodeNotFound {
+		t.Errorf("update of a missing customer: code %d %q", res.Code, res.Remark)
+	}
+
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("ALFKI").WillReturnRows(customerRow(alfki))
+	if res := svc.UpdateCustomerHandler(context.Background(), cm.Customers{CustomerID: "ALFKI"}); res.Code != cm.CodeInvalid {
+		t.Errorf("update without company name: code %d %q", res.Code, res.Remark)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPatchCustomerHandler(t *testing.T) {
+	svc, mock := mockService(t)
+	city := "Bonn"
+
+	//only the fields sent are written
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("ALFKI").WillReturnRows(customerRow(alfki))
+	mock.ExpectExec(`UPDATE customers SET City = \? WHERE CustomerID = \?`).WithArgs("Bonn", "ALFKI").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("ALFKI").WillReturnRows(customerRow(alfki))
+
+	if res := svc.PatchCustomerHandler(context.Background(), cm.CustomerPatch{CustomerID: "ALFKI", City: &city}); res.Code != cm.CodeSuccess {
+		t.Errorf("patch: code %d %q", res.Code, res.Remark)
+	}
+
+	if res := svc.PatchCustomerHandler(context.Background(), cm.CustomerPatch{CustomerID: "ALFKI"}); res.Code != cm.CodeInvalid {
+		t.Errorf("empty patch: code %d %q", res.Code, res.Remark)
+	}
+
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("NOPE").WillReturnRows(sqlmock.NewRows(customerColumns))
+	if res := svc.PatchCustomerHandler(context.Background(), cm.CustomerPatch{CustomerID: "NOPE", City: &city}); res.Code != cm.CodeNotFound {
+		t.Errorf("patch of a missing customer: code %d %q", res.Code, res.Remark)
+	}
+
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WillReturnError(errors.New("connection refused"))
+	if res := svc.PatchCustomerHandler(context.Background(), cm.CustomerPatch{CustomerID: "ALFKI", City: &city}); res.Code != cm.CodeInternal {
+		t.Errorf("patch with the database down: code %d %q", res.Code, res.Remark)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteCustomerHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(sqlmock.Sqlmock)
+		code   int
+	}{
+		{
+			name: "deleted",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT CustomerID FROM customers WHERE CustomerID = \? FOR UPDATE`).WithArgs("ALFKI").
+					WillReturnRows(sqlmock.NewRows([]string{"CustomerID"}).AddRow("ALFKI"))
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders WHERE CustomerID = \?`).WithArgs("ALFKI").
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectExec(`DELETE FROM customers WHERE CustomerID = \?`).WithArgs("ALFKI").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			code: cm.CodeSuccess,
+		},
+		{
+			name: "customer has orders",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FOR UPDATE`).WithArgs("ALFKI").WillReturnRows(sqlmock.NewRows([]string{"CustomerID"}).AddRow("ALFKI"))
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders WHERE CustomerID = \?`).WithArgs("ALFKI").
+					WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(6))
+				mock.ExpectRollback()
+			},
+			code: cm.CodeConflict,
+		},
+		{
+			name: "no such customer",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FOR UPDATE`).WithArgs("ALFKI").WillReturnRows(sqlmock.NewRows([]string{"CustomerID"}))
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders`).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectExec("DELETE FROM customers").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			code: cm.CodeNotFound,
+		},
+		{
+			name: "delete fails",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FOR UPDATE`).WillReturnRows(sqlmock.NewRows([]string{"CustomerID"}).AddRow("ALFKI"))
+				mock.ExpectQuery(`SELECT COUNT\(\*\) FROM orders`).WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+				mock.ExpectExec("DELETE FROM customers").WillReturnError(errors.New("lock wait timeout"))
+				mock.ExpectRollback()
+			},
+			code: cm.CodeInternal,
+		},
+	}
+	for _, tt := range tests {
+		svc, mock := mockService(t)
+		tt.expect(mock)
+
+		if res := svc.DeleteCustomerHandler(context.Background(), cm.Customers{CustomerID: "ALFKI"}); res.Code != tt.code {
+			t.Errorf("%s: code %d %q, want %d", tt.name, res.Code, res.Remark, tt.code)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestInsertOrderLocksCustomer(t *testing.T) {
+	svc, mock := mockService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM customers WHERE CustomerID = \? LOCK IN SHARE MODE`).WithArgs("GONE").
+		WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+	mock.ExpectRollback()
+
+	tx := svc.db(AreaOrders).WithTx()
+	if err := tx.Begin(); err != nil {
+		t.Fatal(err)
+	}
+	remark, err := insertOrder(tx, &cm.Orders{CustomerID: "GONE"})
+	tx.Rollback()
+
+	if err != nil || remark != "CustomerID GONE does not exist" {
+		t.Errorf("insertOrder = %q, %v", remark, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//FindDuplicatesHandler compares the customers matching req and answers the likely duplicates
+func (s PaymentService) FindDuplicatesHandler(ctx context.Context, req cm.DuplicateSearch) (res cm.DuplicateList) {
+
+	defer panicRecovery()
+
+	if remark := duplicateScoreRemark(req.MinScore); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+	minScore := req.MinScore
+	if minScore == 0 {
+		minScore = defaultDuplicateScore
+	}
+
+	sql := `SELECT
+				CustomerID,
+				IFNULL(CompanyName,'') CompanyName,
+				IFNULL(ContactName,'') ContactName,
+				IFNULL(ContactTitle,'') ContactTitle,
+				IFNULL(Address,'') Address,
+				IFNULL(City,'') City,
+				IFNULL(Country,'') Country,
+				IFNULL(Phone,'') Phone,
+				IFNULL(PostalCode,'') PostalCode
+			FROM customers WHERE 1 = 1`
+	var args []interface{}
+	if req.Country != "" {
+		sql += " AND Country = ?"
+		args = append(args, req.Country)
+	}
+	if req.City != "" {
+		sql += " AND City = ?"
+		args = append(args, req.City)
+	}
+
+	customers, err := queryCustomers(s.db(AreaCustomers), sql, args...)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to load customers")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to find duplicates"
+		return
+	}
+
+	pairs, skipped := findDuplicates(customers, minScore)
+	if len(skipped) > 0 {
+		log.WithField("blocks", skipped).Warn("Skipped duplicate blocks larger than ", maxBlockSize)
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Pairs = pairs
+
+	return
+}
+
+//MergeCustomerHandler moves the orders of req.DuplicateID to req.SurvivorID and removes the
+//duplicate, all in one transaction that also records the merge in customer_merges
+func (s PaymentService) MergeCustomerHandler(ctx context.Context, req cm.CustomerMerge) (res cm.MergeResult) {
+
+	defer panicRecovery()
+
+	if req.DuplicateID == "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = "duplicateID is required"
+		return
+	}
+	if strings.EqualFold(req.DuplicateID, req.SurvivorID) {
+		res.Code = cm.CodeInvalid
+		res.Remark = "a customer cannot be merged into itself"
+		return
+	}
+
+	//Validate requires orders to share the customers datasource, so one transaction moves both
+	tx := s.db(AreaCustomers).WithTx()
+	if err := tx.Begin(); err != nil {
+		log.WithField("error", err).Error("Unable to begin transaction")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to merge customers"
+		return
+	}
+	//a no-op once committed, releases the customer locks on any early return or panic
+	defer tx.Rollback()
+
+	fail := func(err error) cm.MergeResult {
+		log.WithField("error", err).Error("Unable to merge customers")
+		return cm.MergeResult{Code: cm.CodeInternal, Remark: "Unable to merge customers"}
+	}
+
+	locked, err := lockCustomers(tx, req.SurvivorID, req.DuplicateID)
+	if err != nil {
+		return fail(err)
+	}
+	for _, id := range []string{req.SurvivorID, req.DuplicateID} {
+		if _, ok := locked[strings.ToUpper(id)]; !ok {
+			res.Code = cm.CodeNotFound
+			res.Remark = fmt.Sprintf("CustomerID %s does not exist", id)
+			return
+		}
+	}
+	survivor := locked[strings.ToUpper(req.SurvivorID)]
+	duplicate := locked[strings.ToUpper(req.DuplicateID)]
+
+	merge := cm.MergeRecord{
+		SurvivorID:  survivor.CustomerID,
+		DuplicateID: duplicate.CustomerID,
+		Reason:      req.Reason,
+		MergedAt:    time.Now().Format(orderDateLayouts[0]),
+		Duplicate:   duplicate,
+	}
+
+	merge.OrdersMoved, err = tx.ExecTx("UPDATE orders SET CustomerID = ? WHERE CustomerID = ?", survivor.CustomerID, duplicate.CustomerID)
+	if err != nil {
+		return fail(err)
+	}
+
+	snapshot, err := json.Marshal(duplicate)
+	if err != nil {
+		return fail(err)
+	}
+	merge.MergeID, err = tx.InsertGetLastIdTx(`INSERT INTO customer_merges (SurvivorID, DuplicateID, Duplicate, OrdersMoved, Reason, MergedAt)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+		merge.SurvivorID, merge.DuplicateID, string(snapshot), merge.OrdersMoved, nullable(merge.Reason), merge.MergedAt)
+	if err != nil {
+		return fail(err)
+	}
+
+	if _, err := tx.ExecTx("DELETE FROM customers WHERE CustomerID = ?", duplicate.CustomerID); err != nil {
+		return fail(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithField("error", err).Error("Unable to commit merge")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to merge customers"
+		return
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Customer = &survivor
+	res.Merge = &merge
+
+	return
+}
+
+//queryCustomers reads the customers selected by sql, which lists the columns of cm.Customers in order
+func queryCustomers(db *database.DbConnection, sql string, args ...interface{}) ([]cm.Customers, error) {
+	result, err := db.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var customers []cm.Customers
+	for result.Next() {
+		var c cm.Customers
+		if err := result.Scan(&c.CustomerID, &c.CompanyName, &c.ContactName, &c.ContactTitle,
+			&c.Address, &c.City, &c.Country, &c.Phone, &c.PostalCode); err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, result.Err()
+}
+
+//lockCustomers reads and locks the customers ids in one statement, so two merges of the same
+//pair lock their rows in the same order, the result is keyed by the upper case CustomerID
+func lockCustomers(tx *database.DbConnection, ids ...string) (map[string]cm.Customers, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	result, err := tx.QueryTx(`SELECT
+				CustomerID,
+				IFNULL(CompanyName,'') CompanyName,
+				IFNULL(ContactName,'') ContactName,
+				IFNULL(ContactTitle,'') ContactTitle,
+				IFNULL(Address,'') Address,
+				IFNULL(City,'') City,
+				IFNULL(Country,'') Country,
+				IFNULL(Phone,'') Phone,
+				IFNULL(PostalCode,'') PostalCode
+			FROM customers WHERE CustomerID IN (`+placeholders+`) ORDER BY CustomerID FOR UPDATE`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	locked := map[string]cm.Customers{}
+	for result.Next() {
+		var c cm.Customers
+		if err := result.Scan(&c.CustomerID, &c.CompanyName, &c.ContactName, &c.ContactTitle,
+			&c.Address, &c.City, &c.Country, &c.Phone, &c.PostalCode); err != nil {
+			return nil, err
+		}
+		locked[strings.ToUpper(c.CustomerID)] = c
+	}
+	return locked, result.Err()
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//CustomerOrdersHandler answers the customer req.CustomerID with one page of their orders
+//matching req, always with their lines
+func (s PaymentService) CustomerOrdersHandler(ctx context.Context, req cm.OrderFilter) (res cm.CustomerOrders) {
+
+	defer panicRecovery()
+
+	c, found, err := findCustomer(s.db(AreaCustomers), req.CustomerID)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to load customer")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to load customer"
+		return
+	}
+	if !found {
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("CustomerID %s does not exist", req.CustomerID)
+		return
+	}
+
+	req.Expand = true
+	list := s.ListOrdersHandler(ctx, req)
+	if list.Code != cm.CodeSuccess {
+		res.Code = list.Code
+		res.Remark = list.Remark
+		return
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Customer = &c
+	res.Orders = list.Orders
+	res.Page = list.Page
+
+	return
+}
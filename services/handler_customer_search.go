@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//SearchCustomersHandler returns one page of the customers matching req, best matches first
+func (s PaymentService) SearchCustomersHandler(ctx context.Context, req cm.CustomerSearch) (res cm.CustomerList) {
+
+	defer panicRecovery()
+
+	q, remark := buildCustomerSearch(req)
+	offset, size, pageRemark := pageBounds(req.Offset, req.PageSize)
+	if remark == "" {
+		remark = pageRemark
+	}
+	if remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	//one extra row tells whether another page follows
+	customers, err := searchCustomers(s.db(AreaCustomers), q, offset, size+1)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to search customers")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to search customers"
+		return
+	}
+	res.Customers = customers
+
+	res.Page = &cm.Page{Offset: offset, PageSize: size}
+	if len(res.Customers) > size {
+		res.Customers = res.Customers[:size]
+		res.Page.HasMore = true
+		res.Page.NextOffset = offset + size
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+
+	return
+}
+
+//searchCustomers reads at most limit customers matching q from offset on, best matches first
+func searchCustomers(db *database.DbConnection, q customerQuery, offset int, limit int) ([]cm.CustomerMatch, error) {
+	sql, args := q.sql()
+
+	result, err := db.Query(sql+" LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	customers := []cm.CustomerMatch{}
+	for result.Next() {
+		var c cm.CustomerMatch
+		if err := result.Scan(&c.CustomerID, &c.CompanyName, &c.ContactName, &c.ContactTitle,
+			&c.Address, &c.City, &c.Country, &c.Phone, &c.PostalCode, &c.Score); err != nil {
+			return nil, err
+		}
+		customers = append(customers, c)
+	}
+	return customers, result.Err()
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/export"
+)
+
+var orderExportHeader = []string{
+	"OrderID", "OrderDate", "CustomerID", "EmployeeID", "Status", "Freight",
+	"ProductID", "ProductName", "UnitPrice", "Quantity", "Discount", "Amount",
+}
+
+var customerExportHeader = []string{
+	"CustomerID", "CompanyName", "ContactName", "ContactTitle", "Address", "City", "Country", "Phone", "PostalCode",
+}
+
+//ExportOrdersHandler exports every order matching req with one row per line, in the
+//order of req.Sort. An order without lines still gets one row with empty line
+//columns. Offset and PageSize are ignored.
+func (s PaymentService) ExportOrdersHandler(ctx context.Context, req cm.OrderExport) (res cm.Export) {
+	q, remark := buildOrderQuery(req.OrderFilter)
+	if remark == "" {
+		remark = exportFormatRemark(req.Format)
+	}
+	if remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	db := s.db(AreaOrders)
+
+	sql := `SELECT
+				o.OrderID,
+				IFNULL(o.OrderDate,'') OrderDate,
+				IFNULL(o.CustomerID,'') CustomerID,
+				IFNULL(o.EmployeeID,'') EmployeeID,
+				o.Status,
+				IFNULL(o.Freight,0) Freight,
+				IFNULL(d.ProductID,'') ProductID,
+				IFNULL(p.ProductName,'') ProductName,
+				IFNULL(d.UnitPrice,0) UnitPrice,
+				IFNULL(d.Quantity,0) Quantity,
+				IFNULL(d.Discount,0) Discount
+			FROM orders o
+				LEFT JOIN order_details d ON (d.OrderID = o.OrderID)
+				LEFT JOIN products p ON (p.ProductID = d.ProductID)` +
+		q.whereSQL() + " ORDER BY " + q.orderBy + ", d.ProductID"
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Name = "orders"
+	res.Format = req.Format
+	res.Header = orderExportHeader
+	res.Rows = func(write func(row []interface{}) error) error {
+		result, err := db.Query(sql, q.args...)
+		if err != nil {
+			return err
+		}
+		defer result.Close()
+
+		for result.Next() {
+			var o cm.Orders
+			var line cm.OrdersDetail
+			if err := result.Scan(&o.OrderID, &o.OrderDate, &o.CustomerID, &o.EmployeeID, &o.Status, &o.Freight,
+				&line.ProductID, &line.ProductName, &line.UnitPrice, &line.Quantity, &line.Discount); err != nil {
+				return err
+			}
+			if err := write([]interface{}{
+				o.OrderID, o.OrderDate, o.CustomerID, o.EmployeeID, o.Status, o.Freight,
+				line.ProductID, line.ProductName, line.UnitPrice, line.Quantity, line.Discount, lineAmount(line),
+			}); err != nil {
+				return err
+			}
+		}
+		return result.Err()
+	}
+
+	return
+}
+
+//ExportCustomersHandler exports every customer matching req ordered by CustomerID
+func (s PaymentService) ExportCustomersHandler(ctx context.Context, req cm.CustomerExport) (res cm.Export) {
+	if remark := exportFormatRemark(req.Format); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	db := s.db(AreaCustomers)
+
+	sql := `SELECT
+				CustomerID,
+				IFNULL(CompanyName,'') CompanyName,
+				IFNULL(ContactName,'') ContactName,
+				IFNULL(ContactTitle,'') ContactTitle,
+				IFNULL(Address,'') Address,
+				IFNULL(City,'') City,
+				IFNULL(Country,'') Country,
+				IFNULL(Phone,'') Phone,
+				IFNULL(PostalCode,'') PostalCode
+			FROM customers WHERE 1 = 1`
+	var args []interface{}
+	if req.Country != "" {
+		sql += " AND Country = ?"
+		args = append(args, req.Country)
+	}
+	if req.City != "" {
+		sql += " AND City = ?"
+		args = append(args, req.City)
+	}
+	sql += " ORDER BY CustomerID"
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Name = "customers"
+	res.Format = req.Format
+	res.Header = customerExportHeader
+	res.Rows = func(write func(row []interface{}) error) error {
+		result, err := db.Query(sql, args...)
+		if err != nil {
+			return err
+		}
+		defer result.Close()
+
+		for result.Next() {
+			var c cm.Customers
+			if err := result.Scan(&c.CustomerID, &c.CompanyName, &c.ContactName, &c.ContactTitle,
+				&c.Address, &c.City, &c.Country, &c.Phone, &c.PostalCode); err != nil {
+				return err
+			}
+			if err := write([]interface{}{
+				c.CustomerID, c.CompanyName, c.ContactName, c.ContactTitle, c.Address, c.City, c.Country, c.Phone, c.PostalCode,
+			}); err != nil {
+				return err
+			}
+		}
+		return result.Err()
+	}
+
+	return
+}
+
+func exportFormatRemark(format string) string {
+	if !export.Supported(format) {
+		return fmt.Sprintf("format must be %s or %s, got %q", cm.FormatCSV, cm.FormatXLSX, format)
+	}
+	return ""
+}
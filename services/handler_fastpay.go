@@ -2,30 +2,16 @@ package services
 
 import (
 	"context"
-	"database/sql"
-	"fmt"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
-func (PaymentService) FastPayHandler(ctx context.Context, req cm.FastPayRequest) (res cm.FastPayResponse) {
+func (s PaymentService) FastPayHandler(ctx context.Context, req cm.FastPayRequest) (res cm.FastPayResponse) {
 
 	defer panicRecovery()
 
-	host := cm.Config.Connection.Host
-	port := cm.Config.Connection.Port
-	user := cm.Config.Connection.User
-	pass := cm.Config.Connection.Password
-	data := cm.Config.Connection.Database
-
-	var mySQL = fmt.Sprintf("%v:%v@tcp(%v:%v)/%v", user, pass, host, port, data)
-
-	db, err = sql.Open("mysql", mySQL)
-
-	if err != nil {
-		panic(err.Error())
+	if rejected, ok := s.rejectFastPay(req); ok {
+		return rejected
 	}
 
 	var fasResponse cm.FastPayResponse
@@ -36,14 +22,14 @@ func (PaymentService) FastPayHandler(ctx context.Context, req cm.FastPayRequest)
 				IFNULL(pg_name,'')
 			FROM list_payment WHERE merchant_id = ?`
 
-	result, err := db.Query(sql, req.MerchantID)
-
-	defer result.Close()
+	result, err := s.db(AreaFastPay).Query(sql, req.MerchantID)
 
 	if err != nil {
 		panic(err.Error())
 	}
 
+	defer result.Close()
+
 	for result.Next() {
 
 		err := result.Scan(&list.PgCode, &list.PgName)
@@ -58,6 +44,8 @@ func (PaymentService) FastPayHandler(ctx context.Context, req cm.FastPayRequest)
 
 	fasResponse.Merchant = req.Merchant
 	fasResponse.MerchantID = req.MerchantID
+	fasResponse.ResponseCode = cm.FastPaySuccess
+	fasResponse.ResponseDesc = "Success"
 
 	res = fasResponse
 
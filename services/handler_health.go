@@ -0,0 +1,111 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+//readinessTimeout bounds every dependency probe made by ReadinessHandler
+const readinessTimeout = 2 * time.Second
+
+//HealthHandler only tells the process is serving requests
+func (s PaymentService) HealthHandler(ctx context.Context) cm.Health {
+	return cm.Health{Status: cm.StatusUp}
+}
+
+//ReadinessHandler pings every datasource and the trip provider concurrently,
+//the service is ready only when all of them answer
+func (s PaymentService) ReadinessHandler(ctx context.Context) (res cm.Health) {
+
+	names := make([]string, 0, len(s.datasources))
+	for name := range s.datasources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res.Checks = make([]cm.DependencyStatus, len(names)+1)
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			res.Checks[i] = probe(ctx, name, "datasource", func(ctx context.Context) error {
+				return s.datasources[name].Db.PingContext(ctx)
+			})
+		}(i, name)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res.Checks[len(names)] = probe(ctx, "trips", "upstream", func(ctx context.Context) error {
+			return pingTrips(ctx, s.conf.TripsURL)
+		})
+	}()
+
+	wg.Wait()
+
+	res.Status = cm.StatusUp
+	for _, c := range res.Checks {
+		if c.Status != cm.StatusUp {
+			res.Status = cm.StatusDown
+		}
+	}
+
+	return
+}
+
+func probe(ctx context.Context, name string, kind string, ping func(context.Context) error) cm.DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	begin := time.Now()
+	err := ping(ctx)
+
+	status := cm.DependencyStatus{
+		Name:      name,
+		Type:      kind,
+		Status:    cm.StatusUp,
+		LatencyMs: float64(time.Since(begin).Nanoseconds()) / float64(1e6),
+	}
+	if err != nil {
+		status.Status = cm.StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
+
+//pingTrips posts an empty trip search, the only request the trip provider serves,
+//and considers it reachable when it answers with a 2xx or 3xx status
+func pingTrips(ctx context.Context, url string) error {
+	body, err := json.Marshal(cm.MyTripsrequest{})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("upstream answered %s", resp.Status)
+	}
+	return nil
+}
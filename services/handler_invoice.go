@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/invoice"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/go-sql-driver/mysql"
+)
+
+//erDupEntry is the MySQL error number of a duplicate key
+const erDupEntry = 1062
+
+//InvoiceHandler renders the invoice of req.OrderID as HTML or PDF from the snapshot taken when
+//it was issued, later changes to the order or customer do not alter it. It never numbers
+//an invoice, an order without one answers CodeNotFound until IssueInvoiceHandler issues it.
+func (s PaymentService) InvoiceHandler(ctx context.Context, req cm.InvoiceRequest) (res cm.Document) {
+
+	defer panicRecovery()
+
+	if remark := invoiceFormatRemark(req.Format); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	inv, found, err := findInvoice(s.db(AreaOrders), req.OrderID)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to load invoice")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to render invoice"
+		return
+	}
+	if !found {
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("order %s has no invoice, issue it with POST /orders/%s/invoice", req.OrderID, req.OrderID)
+		return
+	}
+
+	return s.renderInvoice(req, inv)
+}
+
+//IssueInvoiceHandler numbers the invoice of req.OrderID when it has none yet and renders it
+//as InvoiceHandler does. Issuing again answers the invoice issued first, even once the
+//order is cancelled, a cancelled order without an invoice is not issued one.
+func (s PaymentService) IssueInvoiceHandler(ctx context.Context, req cm.InvoiceRequest) (res cm.Document) {
+
+	defer panicRecovery()
+
+	if remark := invoiceFormatRemark(req.Format); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	inv, status, err := s.issueInvoice(req.OrderID)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to issue invoice")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to issue invoice"
+		return
+	}
+	switch {
+	case status == "":
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("OrderID %s does not exist", req.OrderID)
+		return
+	case inv.Number == "":
+		res.Code = cm.CodeIllegalStatus
+		res.Remark = fmt.Sprintf("order %s is cancelled, it has no invoice", req.OrderID)
+		return
+	}
+
+	return s.renderInvoice(req, inv)
+}
+
+func invoiceFormatRemark(format string) string {
+	if format != cm.FormatHTML && format != cm.FormatPDF {
+		return fmt.Sprintf("format must be %s or %s, got %q", cm.FormatHTML, cm.FormatPDF, format)
+	}
+	return ""
+}
+
+//renderInvoice renders inv in req.Format, the PDF layout is fixed by invoice.PDF while the
+//HTML form comes from the configured template
+func (s PaymentService) renderInvoice(req cm.InvoiceRequest, inv invoice.Invoice) (res cm.Document) {
+	var err error
+	if req.Format == cm.FormatPDF {
+		res.Body, err = invoice.PDF(inv)
+		res.ContentType = "application/pdf"
+	} else {
+		res.Body, err = invoice.HTML(s.conf.Invoice.HTMLTemplate, inv)
+		res.ContentType = "text/html; charset=utf-8"
+	}
+	if err != nil {
+		log.WithField("error", err).Error("Unable to render invoice")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to render invoice"
+		return
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Name = inv.Number + "." + req.Format
+
+	return
+}
+
+//newInvoice is invoice number no of orderID with the order, customer and seller as they are now
+func (s PaymentService) newInvoice(orderID string, no int, issuedAt string) (inv invoice.Invoice, err error) {
+	orders, err := loadOrders(s.db(AreaOrders), []string{orderID})
+	if err != nil {
+		return inv, err
+	}
+	if len(orders) == 0 {
+		return inv, fmt.Errorf("order %s disappeared while its invoice was issued", orderID)
+	}
+	order := orders[0]
+
+	customer, found, err := findCustomer(s.db(AreaCustomers), order.CustomerID)
+	if err != nil {
+		return inv, err
+	}
+	if !found {
+		customer.CustomerID = order.CustomerID
+	}
+
+	conf := s.conf.Invoice
+	return invoice.Invoice{
+		Number:   fmt.Sprintf(conf.NumberFormat, no),
+		IssuedAt: issuedAt,
+		Seller:   conf.Seller,
+		Footer:   conf.Footer,
+		Currency: conf.Currency,
+		Order:    order,
+		Customer: customer,
+	}, nil
+}
+
+//findInvoice returns the snapshot of the invoice of orderID, found is false when it has none
+func findInvoice(db *database.DbConnection, orderID string) (inv invoice.Invoice, found bool, err error) {
+	result, err := db.Query("SELECT Snapshot FROM invoices WHERE OrderID = ?", orderID)
+	if err != nil {
+		return inv, false, err
+	}
+	return scanInvoice(result)
+}
+
+//scanInvoice decodes the snapshot read by result and closes it
+func scanInvoice(result *sql.Rows) (inv invoice.Invoice, found bool, err error) {
+	defer result.Close()
+
+	for result.Next() {
+		var snapshot []byte
+		if err := result.Scan(&snapshot); err != nil {
+			return inv, false, err
+		}
+		if err := json.Unmarshal(snapshot, &inv); err != nil {
+			return inv, false, err
+		}
+		found = true
+	}
+	return inv, found, result.Err()
+}
+
+//issueAttempts bounds the retries of issueInvoice when a concurrent issue took the same number
+const issueAttempts = 3
+
+//issueInvoice returns the invoice of orderID, numbering it MAX + 1 and storing its snapshot
+//when it has none yet. status is the status of the order, empty when it does not exist, a
+//cancelled order is not numbered and gets an empty invoice. The order row is locked first,
+//so two issues of one order run one after the other and the second reads the invoice of the
+//first, and the order cannot change while its snapshot is taken. Issues of different orders
+//serialize on the FOR UPDATE read of the end of the invoices index, a duplicate number left
+//by any other path is retried.
+func (s PaymentService) issueInvoice(orderID string) (inv invoice.Invoice, status string, err error) {
+	for attempt := 1; ; attempt++ {
+		inv, status, err = s.issueInvoiceOnce(orderID)
+		if !isDuplicateKey(err) || attempt == issueAttempts {
+			return inv, status, err
+		}
+		log.WithField("orderID", orderID).Warn("Invoice number taken concurrently, retrying")
+	}
+}
+
+func (s PaymentService) issueInvoiceOnce(orderID string) (inv invoice.Invoice, status string, err error) {
+	tx := s.db(AreaOrders).WithTx()
+	if err := tx.Begin(); err != nil {
+		return inv, "", err
+	}
+	//a no-op once committed, releases the order lock on any early return or panic
+	defer tx.Rollback()
+
+	result, err := tx.QueryTx("SELECT Status FROM orders WHERE OrderID = ? FOR UPDATE", orderID)
+	if err != nil {
+		return inv, "", err
+	}
+	for result.Next() {
+		if err := result.Scan(&status); err != nil {
+			result.Close()
+			return inv, "", err
+		}
+	}
+	result.Close()
+	if status == "" {
+		return inv, "", nil
+	}
+
+	result, err = tx.QueryTx("SELECT Snapshot FROM invoices WHERE OrderID = ?", orderID)
+	if err != nil {
+		return inv, "", err
+	}
+	inv, found, err := scanInvoice(result)
+	if err != nil || found || status == cm.OrderCancelled {
+		return inv, status, err
+	}
+
+	var no int
+	result, err = tx.QueryTx("SELECT IFNULL(MAX(InvoiceNo), 0) + 1 FROM invoices FOR UPDATE")
+	if err != nil {
+		return inv, "", err
+	}
+	for result.Next() {
+		if err := result.Scan(&no); err != nil {
+			result.Close()
+			return inv, "", err
+		}
+	}
+	result.Close()
+
+	issuedAt := time.Now().Format(orderDateLayouts[0])
+	if inv, err = s.newInvoice(orderID, no, issuedAt); err != nil {
+		return inv, "", err
+	}
+	snapshot, err := json.Marshal(inv)
+	if err != nil {
+		return inv, "", err
+	}
+
+	if _, err := tx.ExecTx("INSERT INTO invoices (InvoiceNo, OrderID, IssuedAt, Snapshot) VALUES (?, ?, ?, ?)",
+		no, orderID, issuedAt, snapshot); err != nil {
+		return inv, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return inv, "", err
+	}
+	return inv, status, nil
+}
+
+func isDuplicateKey(err error) bool {
+	e, ok := err.(*mysql.MySQLError)
+	return ok && e.Number == erDupEntry
+}
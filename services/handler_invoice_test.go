@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/invoice"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+)
+
+var customerColumns = []string{"CustomerID", "CompanyName", "ContactName", "ContactTitle", "Address", "City", "Country", "Phone", "PostalCode"}
+
+//mockService serves every area from one sqlmock connection
+func mockService(t *testing.T) (PaymentService, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	svc := NewPaymentService(map[string]*database.DbConnection{"northwind": {Db: db}}, cm.Configuration{DefaultDatasource: "northwind"})
+	return svc, mock
+}
+
+func customerRow(c cm.Customers) *sqlmock.Rows {
+	return sqlmock.NewRows(customerColumns).AddRow(c.CustomerID, c.CompanyName, c.ContactName, c.ContactTitle,
+		c.Address, c.City, c.Country, c.Phone, c.PostalCode)
+}
+
+var alfki = cm.Customers{CustomerID: "ALFKI", CompanyName: "Alfreds Futterkiste", ContactName: "Maria Anders",
+	Address: "Obere Str. 57", City: "Berlin", Country: "Germany", Phone: "+49300074321", PostalCode: "12209"}
+
+
+var testInvoiceConfig = cm.InvoiceConfig{
+	NumberFormat: "INV-%06d",
+	HTMLTemplate: "../templates/invoice.html",
+	Seller:       []string{"MyTrip"},
+	Currency:     "USD",
+}
+
+func invoiceService(t *testing.T) (PaymentService, sqlmock.Sqlmock) {
+	svc, mock := mockService(t)
+	svc.conf.Invoice = testInvoiceConfig
+	return svc, mock
+}
+
+func snapshotRow(t *testing.T, inv invoice.Invoice) *sqlmock.Rows {
+	b, err := json.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlmock.NewRows([]string{"Snapshot"}).AddRow(b)
+}
+
+//snapshotOf matches the Snapshot argument of the invoice insert and keeps it
+type snapshotOf struct {
+	inv *invoice.Invoice
+}
+
+func (s snapshotOf) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	return ok && json.Unmarshal(b, s.inv) == nil
+}
+
+//expectNewInvoice queues the reads of a first issue of order 10248 up to the next number
+func expectNewInvoice(mock sqlmock.Sqlmock, next int) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT Status FROM orders WHERE OrderID = \? FOR UPDATE`).WithArgs("10248").
+		WillReturnRows(sqlmock.NewRows([]string{"Status"}).AddRow(cm.OrderPaid))
+	mock.ExpectQuery(`SELECT Snapshot FROM invoices WHERE OrderID = \?`).WithArgs("10248").
+		WillReturnRows(sqlmock.NewRows([]string{"Snapshot"}))
+	mock.ExpectQuery(`SELECT IFNULL\(MAX\(InvoiceNo\), 0\) \+ 1 FROM invoices FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"next"}).AddRow(next))
+	expectBatched(mock, []string{"10248"}, 0)
+	mock.ExpectQuery("FROM customers WHERE CustomerID").WithArgs("VINET").WillReturnRows(customerRow(cm.Customers{
+		CustomerID: "VINET", CompanyName: "Vins et alcools Chevalier", City: "Reims", Country: "France"}))
+}
+
+func TestIssueInvoiceNumbersUnderLock(t *testing.T) {
+	svc, mock := invoiceService(t)
+
+	var stored invoice.Invoice
+	expectNewInvoice(mock, 42)
+	mock.ExpectExec(`INSERT INTO invoices \(InvoiceNo, OrderID, IssuedAt, Snapshot\)`).
+		WithArgs(42, "10248", sqlmock.AnyArg(), snapshotOf{&stored}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res := svc.IssueInvoiceHandler(context.Background(), cm.InvoiceRequest{OrderID: "10248", Format: cm.FormatHTML})
+	if res.Code != cm.CodeSuccess || res.Name != "INV-000042.html" {
+		t.Fatalf("issue: code %d %q, name %q", res.Code, res.Remark, res.Name)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Number != "INV-000042" || stored.Customer.CompanyName != "Vins et alcools Chevalier" ||
+		len(stored.Order.OrdersDet) != 2 || stored.Order.Total.String() != "283.68" {
+		t.Errorf("snapshot %+v", stored)
+	}
+	for _, want := range []string{"INV-000042", "Vins et alcools Chevalier", "Queso Cabrales"} {
+		if !strings.Contains(string(res.Body), want) {
+			t.Errorf("rendered invoice does not contain %q", want)
+		}
+	}
+}
+
+func TestIssueInvoiceRetriesTakenNumber(t *testing.T) {
+	svc, mock := invoiceService(t)
+
+	expectNewInvoice(mock, 42)
+	mock.ExpectExec("INSERT INTO invoices").WithArgs(42, "10248", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&mysql.MySQLError{Number: erDupEntry})
+	mock.ExpectRollback()
+	expectNewInvoice(mock, 43)
+	mock.ExpectExec("INSERT INTO invoices").WithArgs(43, "10248", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res := svc.IssueInvoiceHandler(context.Background(), cm.InvoiceRequest{OrderID: "10248", Format: cm.FormatPDF})
+	if res.Code != cm.CodeSuccess || res.Name != "INV-000043.pdf" || !strings.HasPrefix(string(res.Body), "%PDF") {
+		t.Errorf("issue: code %d %q, name %q", res.Code, res.Remark, res.Name)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIssueInvoiceAnswersIssued(t *testing.T) {
+	issued := invoice.Invoice{Number: "INV-000007", IssuedAt: "1996-07-20 10:00:00",
+		Order: cm.Orders{OrderID: "10248"}, Customer: cm.Customers{CustomerID: "VINET", CompanyName: "Vins et alcools Chevalier"}}
+
+	tests := []struct {
+		name     string
+		status   string
+		snapshot bool
+		code     int
+	}{
+		{"issued", cm.OrderPaid, true, cm.CodeSuccess},
+		{"issued before the order was cancelled", cm.OrderCancelled, true, cm.CodeSuccess},
+		{"cancelled without invoice", cm.OrderCancelled, false, cm.CodeIllegalStatus},
+		{"no such order", "", false, cm.CodeNotFound},
+	}
+	for _, tt := range tests {
+		svc, mock := invoiceService(t)
+
+		mock.ExpectBegin()
+		status := sqlmock.NewRows([]string{"Status"})
+		if tt.status != "" {
+			status.AddRow(tt.status)
+		}
+		mock.ExpectQuery("FOR UPDATE").WithArgs("10248").WillReturnRows(status)
+		if tt.status != "" {
+			rows := sqlmock.NewRows([]string{"Snapshot"})
+			if tt.snapshot {
+				rows = snapshotRow(t, issued)
+			}
+			mock.ExpectQuery("SELECT Snapshot FROM invoices").WithArgs("10248").WillReturnRows(rows)
+		}
+		mock.ExpectRollback()
+
+		res := svc.IssueInvoiceHandler(context.Background(), cm.InvoiceRequest{OrderID: "10248", Format: cm.FormatHTML})
+		if res.Code != tt.code {
+			t.Errorf("%s: code %d %q, want %d", tt.name, res.Code, res.Remark, tt.code)
+		}
+		if tt.code == cm.CodeSuccess && res.Name != "INV-000007.html" {
+			t.Errorf("%s: answered %q instead of the issued invoice", tt.name, res.Name)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestInvoiceHandlerRendersSnapshot(t *testing.T) {
+	svc, mock := invoiceService(t)
+
+	//the customer has been renamed and the order cancelled since, neither is read again
+	mock.ExpectQuery(`SELECT Snapshot FROM invoices WHERE OrderID = \?`).WithArgs("10248").WillReturnRows(snapshotRow(t, invoice.Invoice{
+		Number:   "INV-000007",
+		IssuedAt: "1996-07-20 10:00:00",
+		Currency: "USD",
+		Order:    cm.Orders{OrderID: "10248", Status: cm.OrderPaid},
+		Customer: cm.Customers{CustomerID: "VINET", CompanyName: "Vins et alcools Chevalier"},
+	}))
+
+	res := svc.InvoiceHandler(context.Background(), cm.InvoiceRequest{OrderID: "10248", Format: cm.FormatHTML})
+	if res.Code != cm.CodeSuccess || res.Name != "INV-000007.html" {
+		t.Fatalf("render: code %d %q, name %q", res.Code, res.Remark, res.Name)
+	}
+	for _, want := range []string{"INV-000007", "1996-07-20 10:00:00", "Vins et alcools Chevalier"} {
+		if !strings.Contains(string(res.Body), want) {
+			t.Errorf("rendered invoice does not contain %q", want)
+		}
+	}
+
+	mock.ExpectQuery("SELECT Snapshot FROM invoices").WithArgs("10249").WillReturnRows(sqlmock.NewRows([]string{"Snapshot"}))
+	if res := svc.InvoiceHandler(context.Background(), cm.InvoiceRequest{OrderID: "10249", Format: cm.FormatPDF}); res.Code != cm.CodeNotFound {
+		t.Errorf("order without invoice: code %d %q", res.Code, res.Remark)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
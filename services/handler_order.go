@@ -2,105 +2,45 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"strconv"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
 
-	_ "github.com/go-sql-driver/mysql"
+	log "github.com/Sirupsen/logrus"
 )
 
-func (PaymentService) OrderHandler(ctx context.Context, req cm.Message) (res cm.Message) {
-
-	var db *sql.DB
-	var err error
+//OrderHandler answers the order req.OrderID with its lines and totals, CodeNotFound when
+//there is no such order and CodeInternal when it cannot be read
+func (s PaymentService) OrderHandler(ctx context.Context, req cm.Message) (res cm.Message) {
 
 	defer panicRecovery()
 
-	host := cm.Config.Connection.Host
-	port := cm.Config.Connection.Port
-	user := cm.Config.Connection.User
-	pass := cm.Config.Connection.Password
-	data := cm.Config.Connection.Database
-
-	var mySQL = fmt.Sprintf("%v:%v@tcp(%v:%v)/%v", user, pass, host, port, data)
-
-	db, err = sql.Open("mysql", mySQL)
-
-	if err != nil {
-		panic(err.Error())
-	}
-
 	res.OrderID = req.OrderID
 
-	var order cm.Orders
-	var orderdet cm.OrdersDetail
-
-	sql := `SELECT
-				OrderID,
-				IFNULL(CustomerID,'') CustomerID,
-				IFNULL(EmployeeID,'') EmployeeID,
-				IFNULL(OrderDate,'') OrderDate				
-			FROM orders WHERE OrderID = ?`
-
-	result, err := db.Query(sql, req.OrderID)
-
-	defer result.Close()
-
-	if err != nil {
-		panic(err.Error())
+	if _, err := strconv.ParseUint(req.OrderID, 10, 64); err != nil {
+		res.Code = cm.CodeInvalid
+		res.Remark = "OrderID must be a number"
+		return
 	}
 
-	for result.Next() {
-
-		err := result.Scan(&order.OrderID, &order.CustomerID, &order.EmployeeID, &order.OrderDate)
-
-		if err != nil {
-			panic(err.Error())
-		}
-
-		sqlDetial := `SELECT
-						order_details.OrderID		
-						, products.ProductID
-						, products.ProductName
-						, order_details.UnitPrice
-						, order_details.Quantity
-					FROM
-						order_details
-						INNER JOIN products 
-							ON (order_details.ProductID = products.ProductID)
-					WHERE order_details.OrderID	= ?`
-
-		orderID := &order.OrderID
-		fmt.Println(*orderID)
-		resultDetail, errDet := db.Query(sqlDetial, *orderID)
-
-		defer resultDetail.Close()
-
-		if errDet != nil {
-			panic(err.Error())
-		}
-
-		for resultDetail.Next() {
-
-			err := resultDetail.Scan(&orderdet.OrderID, &orderdet.ProductID, &orderdet.ProductName, &orderdet.UnitPrice, &orderdet.Quantity)
-
-			if err != nil {
-				panic(err.Error())
-			}
-
-			order.OrdersDet = append(order.OrdersDet, orderdet)
-
-		}
-
+	orders, err := loadOrders(s.db(AreaOrders), []string{req.OrderID})
+	if err != nil {
+		log.WithField("error", err).Error("Unable to load order")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to load order"
+		return
 	}
 
-	if &order != nil {
-		res.Code = 100
-		res.Remark = "Success"
+	if len(orders) == 0 {
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("OrderID %s does not exist", req.OrderID)
+		return
 	}
 
-	res.Orders = &order
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.Orders = &orders[0]
 
 	return
 }
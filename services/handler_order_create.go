@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//orderDateLayouts are the accepted OrderDate formats, the first one is used when OrderDate is empty
+var orderDateLayouts = []string{"2006-01-02 15:04:05", "2006-01-02"}
+
+//CreateOrderHandler inserts req and its lines into orders and order_details in one transaction
+func (s PaymentService) CreateOrderHandler(ctx context.Context, req cm.Orders) (res cm.Message) {
+
+	defer panicRecovery()
+
+	if remark := validateNewOrder(&req); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	tx := s.db(AreaOrders).WithTx()
+	if err := tx.Begin(); err != nil {
+		log.WithField("error", err).Error("Unable to begin transaction")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to create order"
+		return
+	}
+	//a no-op once committed, releases the rows locked so far on any early return or panic
+	defer tx.Rollback()
+
+	remark, err := insertOrder(tx, &req)
+	if err != nil || remark != "" {
+		if err != nil {
+			log.WithField("error", err).Error("Unable to create order")
+			res.Code = cm.CodeInternal
+			res.Remark = "Unable to create order"
+		} else {
+			res.Code = cm.CodeInvalid
+			res.Remark = remark
+		}
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithField("error", err).Error("Unable to commit order")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to create order"
+		return
+	}
+
+	computeTotals(&req)
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+	res.OrderID = req.OrderID
+	res.Orders = &req
+
+	return
+}
+
+//validateNewOrder checks what can be checked without the database and fills OrderDate
+func validateNewOrder(req *cm.Orders) string {
+	if req.OrderID != "" {
+		return "OrderID is generated and must be empty, look orders up with GET /orders/{orderID}"
+	}
+	if req.CustomerID == "" {
+		return "CustomerID is required"
+	}
+	if req.Freight.IsNegative() {
+		return "freight must not be negative"
+	}
+	if req.OrderDate == "" {
+		req.OrderDate = time.Now().Format(orderDateLayouts[0])
+	} else if !validOrderDate(req.OrderDate) {
+		return fmt.Sprintf("OrderDate must look like %q or %q", orderDateLayouts[0], orderDateLayouts[1])
+	}
+
+	return validateLines(req.OrdersDet)
+}
+
+//validateLines checks the lines of a new or updated order
+func validateLines(lines []cm.OrdersDetail) string {
+	if len(lines) == 0 {
+		return "ordersDetail needs at least one line"
+	}
+
+	seen := map[string]bool{}
+	for i, line := range lines {
+		if line.ProductID == "" {
+			return fmt.Sprintf("ordersDetail[%d].ProductID is required", i)
+		}
+		if seen[line.ProductID] {
+			return fmt.Sprintf("ordersDetail[%d].ProductID %s appears more than once", i, line.ProductID)
+		}
+		seen[line.ProductID] = true
+		if line.Quantity <= 0 {
+			return fmt.Sprintf("ordersDetail[%d].Quantity must be positive", i)
+		}
+		if line.UnitPrice.IsNegative() {
+			return fmt.Sprintf("ordersDetail[%d].UnitPrice must not be negative", i)
+		}
+		if line.Discount.IsNegative() || line.Discount.GreaterThanOrEqual(one) {
+			return fmt.Sprintf("ordersDetail[%d].Discount must be a fraction from 0 up to 1, e.g. 0.15", i)
+		}
+	}
+
+	return ""
+}
+
+func validOrderDate(v string) bool {
+	for _, layout := range orderDateLayouts {
+		if _, err := time.Parse(layout, v); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+//insertOrder checks customer and products inside tx and inserts the order, a non empty
+//remark means the request refers to rows that do not exist. The customer row stays share
+//locked until tx ends, so it cannot be deleted or merged away under the new order
+func insertOrder(tx *database.DbConnection, req *cm.Orders) (string, error) {
+
+	var found int
+	result, err := tx.QueryTx("SELECT COUNT(*) FROM customers WHERE CustomerID = ? LOCK IN SHARE MODE", req.CustomerID)
+	if err != nil {
+		return "", err
+	}
+	for result.Next() {
+		if err := result.Scan(&found); err != nil {
+			result.Close()
+			return "", err
+		}
+	}
+	result.Close()
+	if found == 0 {
+		return fmt.Sprintf("CustomerID %s does not exist", req.CustomerID), nil
+	}
+
+	if remark, err := resolveProducts(tx, req.OrdersDet); err != nil || remark != "" {
+		return remark, err
+	}
+
+	var employeeID interface{}
+	if req.EmployeeID != "" {
+		employeeID = req.EmployeeID
+	}
+
+	id, err := tx.InsertGetLastIdTx("INSERT INTO orders (CustomerID, EmployeeID, OrderDate, Freight) VALUES (?, ?, ?, ?)",
+		req.CustomerID, employeeID, req.OrderDate, req.Freight)
+	if err != nil {
+		return "", err
+	}
+	req.OrderID = strconv.FormatInt(id, 10)
+	req.Status = cm.OrderPendingPayment
+	req.Version = 1
+
+	if err := setStatus(tx, req.OrderID, "", req.Status, ""); err != nil {
+		return "", err
+	}
+
+	return "", insertLines(tx, req.OrderID, req.OrdersDet)
+}
+
+//resolveProducts fills ProductName of lines and UnitPrice when none was given from the products table,
+//a non empty remark names the first line whose product does not exist
+func resolveProducts(tx *database.DbConnection, lines []cm.OrdersDetail) (string, error) {
+	products, err := findProducts(tx, lines)
+	if err != nil {
+		return "", err
+	}
+	for i, line := range lines {
+		p, ok := products[line.ProductID]
+		if !ok {
+			return fmt.Sprintf("ordersDetail[%d].ProductID %s does not exist", i, line.ProductID), nil
+		}
+		lines[i].ProductName = p.ProductName
+		if !line.PriceGiven {
+			lines[i].UnitPrice = p.UnitPrice
+		}
+	}
+	return "", nil
+}
+
+//insertLines stores lines under orderID
+func insertLines(tx *database.DbConnection, orderID string, lines []cm.OrdersDetail) error {
+	for i, line := range lines {
+		lines[i].OrderID = orderID
+		if _, err := tx.ExecTx("INSERT INTO order_details (OrderID, ProductID, UnitPrice, Quantity, Discount) VALUES (?, ?, ?, ?, ?)",
+			orderID, line.ProductID, line.UnitPrice, line.Quantity, line.Discount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//findProducts loads the products referenced by lines, keyed by ProductID
+func findProducts(tx *database.DbConnection, lines []cm.OrdersDetail) (map[string]cm.OrdersDetail, error) {
+	args := make([]interface{}, len(lines))
+	for i, line := range lines {
+		args[i] = line.ProductID
+	}
+
+	sql := `SELECT ProductID, ProductName, IFNULL(UnitPrice, 0)
+			FROM products WHERE ProductID IN (?` + strings.Repeat(", ?", len(lines)-1) + `)`
+
+	result, err := tx.QueryTx(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	products := map[string]cm.OrdersDetail{}
+	for result.Next() {
+		var p cm.OrdersDetail
+		if err := result.Scan(&p.ProductID, &p.ProductName, &p.UnitPrice); err != nil {
+			return nil, err
+		}
+		products[p.ProductID] = p
+	}
+
+	return products, result.Err()
+}
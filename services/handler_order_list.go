@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//ListOrdersHandler returns one page of order headers with their totals matching req,
+//their lines are included when req.Expand is set
+func (s PaymentService) ListOrdersHandler(ctx context.Context, req cm.OrderFilter) (res cm.OrderList) {
+
+	defer panicRecovery()
+
+	q, remark := buildOrderQuery(req)
+	offset, size, pageRemark := pageBounds(req.Offset, req.PageSize)
+	if remark == "" {
+		remark = pageRemark
+	}
+	if remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	orders, err := listOrders(s.db(AreaOrders), q, offset, size+1)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to list orders")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to list orders"
+		return
+	}
+
+	//the extra row asked for tells whether another page follows
+	res.Orders = orders
+	res.Page = &cm.Page{Offset: offset, PageSize: size}
+	if len(res.Orders) > size {
+		res.Orders = res.Orders[:size]
+		res.Page.HasMore = true
+		res.Page.NextOffset = offset + size
+	}
+
+	if !req.Expand {
+		for i := range res.Orders {
+			res.Orders[i].OrdersDet = nil
+		}
+	}
+
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+
+	return
+}
+
+//listOrders reads up to limit orders matching q from offset on, with their lines and totals
+func listOrders(db *database.DbConnection, q orderQuery, offset int, limit int) ([]cm.Orders, error) {
+	result, err := db.Query(q.sql()+" LIMIT ? OFFSET ?", append(q.args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	orders := []cm.Orders{}
+	for result.Next() {
+		var order cm.Orders
+		if err := result.Scan(&order.OrderID, &order.CustomerID, &order.EmployeeID, &order.OrderDate, &order.Status, &order.Version, &order.Freight); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	if err := result.Err(); err != nil {
+		return nil, err
+	}
+
+	//lines are always loaded as the totals are computed from them
+	if err := loadOrderDetails(db, orders); err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		computeTotals(&orders[i])
+	}
+
+	return orders, nil
+}
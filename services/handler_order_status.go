@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//TransitionOrderHandler moves the order to req.Status when its current status allows it,
+//CodeIllegalStatus answers a move the lifecycle does not allow
+func (s PaymentService) TransitionOrderHandler(ctx context.Context, req cm.OrderUpdate) (res cm.Message) {
+	if !knownStatus(req.Status) {
+		res.OrderID = req.OrderID
+		res.Code = cm.CodeInvalid
+		res.Remark = fmt.Sprintf("status must be one of %s", strings.Join(orderStatuses, ", "))
+		return
+	}
+	if len(req.Reason) > 255 {
+		res.OrderID = req.OrderID
+		res.Code = cm.CodeInvalid
+		res.Remark = "reason must be at most 255 characters"
+		return
+	}
+
+	return s.updateOrder(ctx, req, "change order status", movesTo(req.Status), func(tx *database.DbConnection, cur lockedOrder) (string, error) {
+		return "", setStatus(tx, req.OrderID, cur.status, req.Status, req.Reason)
+	})
+}
+
+//OrderHistoryHandler answers the status changes of the order, oldest first
+func (s PaymentService) OrderHistoryHandler(ctx context.Context, req cm.Message) (res cm.StatusHistory) {
+
+	defer panicRecovery()
+
+	res.OrderID = req.OrderID
+
+	history, found, err := statusHistory(s.db(AreaOrders), req.OrderID)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to load order history")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to load order history"
+		return
+	}
+	if !found {
+		res.Code = cm.CodeNotFound
+		res.Remark = fmt.Sprintf("OrderID %s does not exist", req.OrderID)
+		return
+	}
+
+	res.History = history
+	res.Code = cm.CodeSuccess
+	res.Remark = "Success"
+
+	return
+}
+
+//statusHistory reads the status changes of the order oldest first, found is false when
+//the order does not exist
+func statusHistory(db *database.DbConnection, orderID string) (history []cm.StatusChange, found bool, err error) {
+	var count int
+	result, err := db.Query("SELECT COUNT(*) FROM orders WHERE OrderID = ?", orderID)
+	if err != nil {
+		return nil, false, err
+	}
+	for result.Next() {
+		if err := result.Scan(&count); err != nil {
+			result.Close()
+			return nil, false, err
+		}
+	}
+	result.Close()
+	if count == 0 {
+		return nil, false, nil
+	}
+
+	result, err = db.Query(`SELECT IFNULL(FromStatus,''), ToStatus, IFNULL(Reason,''), ChangedAt
+			FROM order_status_history WHERE OrderID = ? ORDER BY HistoryID`, orderID)
+	if err != nil {
+		return nil, true, err
+	}
+	defer result.Close()
+
+	history = []cm.StatusChange{}
+	for result.Next() {
+		var c cm.StatusChange
+		if err := result.Scan(&c.FromStatus, &c.ToStatus, &c.Reason, &c.ChangedAt); err != nil {
+			return nil, true, err
+		}
+		history = append(history, c)
+	}
+	return history, true, result.Err()
+}
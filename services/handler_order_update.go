@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//lockedOrder is the state of an order read under FOR UPDATE before changing it
+type lockedOrder struct {
+	version int
+	status  string
+}
+
+//UpdateOrderLinesHandler replaces every line of the order with req.OrdersDet
+func (s PaymentService) UpdateOrderLinesHandler(ctx context.Context, req cm.OrderUpdate) (res cm.Message) {
+	if remark := validateLines(req.OrdersDet); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	guard := inStatus("change the lines", cm.OrderPendingPayment)
+	return s.updateOrder(ctx, req, "update order lines", guard, func(tx *database.DbConnection, cur lockedOrder) (string, error) {
+		if remark, err := resolveProducts(tx, req.OrdersDet); err != nil || remark != "" {
+			return remark, err
+		}
+		if _, err := tx.ExecTx("DELETE FROM order_details WHERE OrderID = ?", req.OrderID); err != nil {
+			return "", err
+		}
+		return "", insertLines(tx, req.OrderID, req.OrdersDet)
+	})
+}
+
+//UpdateShippingHandler replaces the shipping information of the order with req.Shipping
+func (s PaymentService) UpdateShippingHandler(ctx context.Context, req cm.OrderUpdate) (res cm.Message) {
+	if remark := validateShipping(req.Shipping); remark != "" {
+		res.Code = cm.CodeInvalid
+		res.Remark = remark
+		return
+	}
+
+	guard := inStatus("change the shipping", cm.OrderPendingPayment, cm.OrderPaid)
+	return s.updateOrder(ctx, req, "update shipping", guard, func(tx *database.DbConnection, cur lockedOrder) (string, error) {
+		sh := req.Shipping
+		_, err := tx.ExecTx(`UPDATE orders SET ShipName = ?, ShipAddress = ?, ShipCity = ?, ShipRegion = ?,
+				ShipPostalCode = ?, ShipCountry = ?, ShipVia = ?, RequiredDate = ?
+			WHERE OrderID = ?`,
+			nullable(sh.ShipName), nullable(sh.ShipAddress), nullable(sh.ShipCity), nullable(sh.ShipRegion),
+			nullable(sh.ShipPostalCode), nullable(sh.ShipCountry), nullable(sh.ShipVia), nullable(sh.RequiredDate),
+			req.OrderID)
+		return "", err
+	})
+}
+
+//CancelOrderHandler moves the order to cancelled, its lines are kept
+func (s PaymentService) CancelOrderHandler(ctx context.Context, req cm.OrderUpdate) (res cm.Message) {
+	req.Status = cm.OrderCancelled
+	return s.TransitionOrderHandler(ctx, req)
+}
+
+//updateOrder runs apply in a transaction once the order is locked, req.Version is checked
+//against the stored one and guard accepts its status, then bumps the version and answers the
+//updated order. A non empty remark from apply rolls back and is answered as an invalid request.
+func (s PaymentService) updateOrder(ctx context.Context, req cm.OrderUpdate, action string, guard orderGuard,
+	apply func(tx *database.DbConnection, cur lockedOrder) (string, error)) (res cm.Message) {
+
+	defer panicRecovery()
+
+	res.OrderID = req.OrderID
+
+	if req.Version <= 0 {
+		res.Code = cm.CodeInvalid
+		res.Remark = "version is required, send the ETag of the order as If-Match"
+		return
+	}
+
+	tx := s.db(AreaOrders).WithTx()
+	if err := tx.Begin(); err != nil {
+		log.WithField("error", err).Error("Unable to begin transaction")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to " + action
+		return
+	}
+	//a no-op once committed, releases the order lock on any early return or panic
+	defer tx.Rollback()
+
+	fail := func(code int, remark string) cm.Message {
+		res.Code = code
+		res.Remark = remark
+		return res
+	}
+
+	cur, found, err := lockOrder(tx, req.OrderID)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to lock order")
+		return fail(cm.CodeInternal, "Unable to "+action)
+	}
+	switch {
+	case !found:
+		return fail(cm.CodeNotFound, fmt.Sprintf("OrderID %s does not exist", req.OrderID))
+	case cur.version != req.Version:
+		return fail(cm.CodeConflict, fmt.Sprintf("order %s was changed by someone else, reload it, it is now at version %d", req.OrderID, cur.version))
+	}
+	if remark := guard(cur.status); remark != "" {
+		return fail(cm.CodeIllegalStatus, remark)
+	}
+
+	remark, err := apply(tx, cur)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to " + action)
+		return fail(cm.CodeInternal, "Unable to "+action)
+	}
+	if remark != "" {
+		return fail(cm.CodeInvalid, remark)
+	}
+
+	n, err := tx.ExecTx("UPDATE orders SET Version = Version + 1 WHERE OrderID = ? AND Version = ?", req.OrderID, req.Version)
+	if err != nil {
+		log.WithField("error", err).Error("Unable to bump order version")
+		return fail(cm.CodeInternal, "Unable to "+action)
+	}
+	if n == 0 {
+		return fail(cm.CodeConflict, fmt.Sprintf("order %s was changed by someone else, reload it", req.OrderID))
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.WithField("error", err).Error("Unable to commit order")
+		res.Code = cm.CodeInternal
+		res.Remark = "Unable to " + action
+		return
+	}
+
+	return s.OrderHandler(ctx, cm.Message{OrderID: req.OrderID})
+}
+
+//lockOrder reads the order with FOR UPDATE so concurrent updates of it wait for tx
+func lockOrder(tx *database.DbConnection, orderID string) (cur lockedOrder, found bool, err error) {
+	result, err := tx.QueryTx("SELECT Version, Status FROM orders WHERE OrderID = ? FOR UPDATE", orderID)
+	if err != nil {
+		return cur, false, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		if err := result.Scan(&cur.version, &cur.status); err != nil {
+			return cur, false, err
+		}
+		found = true
+	}
+	return cur, found, result.Err()
+}
+
+func validateShipping(sh *cm.Shipping) string {
+	if sh == nil {
+		return "shipping is required"
+	}
+	if sh.ShippedDate != "" {
+		return "shippedDate cannot be changed through shipping"
+	}
+	if sh.ShipVia != "" {
+		if _, err := strconv.Atoi(sh.ShipVia); err != nil {
+			return "shipVia must be a ShipperID"
+		}
+	}
+	if sh.RequiredDate != "" && !validOrderDate(sh.RequiredDate) {
+		return fmt.Sprintf("requiredDate must look like %q or %q", orderDateLayouts[0], orderDateLayouts[1])
+	}
+	return ""
+}
+
+//nullable stores empty strings as NULL
+func nullable(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"bytes"
 	"context"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -11,10 +10,10 @@ import (
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
 
-	_ "github.com/go-sql-driver/mysql"
+	log "github.com/Sirupsen/logrus"
 )
 
-func (PaymentService) TripsHandler(ctx context.Context, req cm.MyTripsrequest) (res cm.MytripsResponse) {
+func (s PaymentService) TripsHandler(ctx context.Context, req cm.MyTripsrequest) (res cm.MytripsResponse) {
 
 	msg := &cm.MyTripsrequest{
 		Provinsi:      req.Provinsi,
@@ -28,7 +27,7 @@ func (PaymentService) TripsHandler(ctx context.Context, req cm.MyTripsrequest) (
 		print(err)
 	}
 
-	resp, err := http.Post("http://35.186.147.192/travel/GetTripsSample.php", "application/json", bytes.NewBuffer(reqBody))
+	resp, err := http.Post(s.conf.TripsURL, "application/json", bytes.NewBuffer(reqBody))
 
 	if err != nil {
 		print(err)
@@ -49,16 +48,7 @@ func (PaymentService) TripsHandler(ctx context.Context, req cm.MyTripsrequest) (
 	res.Status = response.Status
 	res.TripDetail = response.TripDetail
 
-	host := cm.Config.Connection.Host
-	port := cm.Config.Connection.Port
-	user := cm.Config.Connection.User
-	pass := cm.Config.Connection.Password
-	data := cm.Config.Connection.Database
-	var mySQL = fmt.Sprintf("%v:%v@tcp(%v:%v)/%v", user, pass, host, port, data)
-	db, err := sql.Open("mysql", mySQL)
-	if err != nil {
-		panic(err.Error())
-	}
+	db := s.db(AreaTrips)
 
 	for _, data := range response.TripDetail {
 		AirlineName := data.AirlineName
@@ -70,13 +60,10 @@ func (PaymentService) TripsHandler(ctx context.Context, req cm.MyTripsrequest) (
 		fmt.Println("AirlineName : ", CityName)
 
 		sql := "INSERT INTO `trip` (`AirlineName`, `AirportName`, `CityName`) VALUES (?, ?, ?)"
-		stmt, err := db.Prepare(sql)
-		if err != nil {
-			panic(err.Error())
+		if _, err := db.Exec(sql, AirlineName, AirportName, CityName); err != nil {
+			log.WithField("error", err).Error("Unable to store trip")
 		}
 
-		_, err = stmt.Exec(AirlineName, AirportName, CityName)
-
 	}
 
 	return
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+//orderSorts maps the accepted sort keys to their column, prefix a key with - to sort descending
+var orderSorts = map[string]string{
+	"orderID":    "o.OrderID",
+	"orderDate":  "o.OrderDate",
+	"customerID": "o.CustomerID",
+	"employeeID": "o.EmployeeID",
+}
+
+const orderColumns = `SELECT
+				o.OrderID,
+				IFNULL(o.CustomerID,'') CustomerID,
+				IFNULL(o.EmployeeID,'') EmployeeID,
+				IFNULL(o.OrderDate,'') OrderDate,
+				o.Status,
+				o.Version,
+				IFNULL(o.Freight,0) Freight
+			FROM orders o`
+
+//orderQuery is the WHERE and ORDER BY built from an OrderFilter
+type orderQuery struct {
+	where   []string
+	args    []interface{}
+	orderBy string
+}
+
+//buildOrderQuery validates f and translates it to SQL, a non empty remark describes the invalid field
+func buildOrderQuery(f cm.OrderFilter) (q orderQuery, remark string) {
+	if f.CustomerID != "" {
+		q.where = append(q.where, "o.CustomerID = ?")
+		q.args = append(q.args, f.CustomerID)
+	}
+	if f.EmployeeID != "" {
+		q.where = append(q.where, "o.EmployeeID = ?")
+		q.args = append(q.args, f.EmployeeID)
+	}
+	if f.ProductID != "" {
+		q.where = append(q.where, "EXISTS (SELECT 1 FROM order_details d WHERE d.OrderID = o.OrderID AND d.ProductID = ?)")
+		q.args = append(q.args, f.ProductID)
+	}
+	if f.Status != "" {
+		if !knownStatus(f.Status) {
+			return q, fmt.Sprintf("status must be one of %s", strings.Join(orderStatuses, ", "))
+		}
+		q.where = append(q.where, "o.Status = ?")
+		q.args = append(q.args, f.Status)
+	}
+	if f.DateFrom != "" {
+		if _, err := time.Parse("2006-01-02", f.DateFrom); err != nil {
+			return q, "dateFrom must look like 2006-01-02"
+		}
+		q.where = append(q.where, "o.OrderDate >= ?")
+		q.args = append(q.args, f.DateFrom)
+	}
+	if f.DateTo != "" {
+		if _, err := time.Parse("2006-01-02", f.DateTo); err != nil {
+			return q, "dateTo must look like 2006-01-02"
+		}
+		q.where = append(q.where, "o.OrderDate < DATE_ADD(?, INTERVAL 1 DAY)")
+		q.args = append(q.args, f.DateTo)
+	}
+
+	key, dir := strings.TrimPrefix(f.Sort, "-"), "ASC"
+	if strings.HasPrefix(f.Sort, "-") {
+		dir = "DESC"
+	}
+	if key == "" {
+		key = "orderID"
+	}
+	column, ok := orderSorts[key]
+	if !ok {
+		return q, fmt.Sprintf("sort must be one of orderID, orderDate, customerID, employeeID optionally prefixed with -, got %q", f.Sort)
+	}
+	q.orderBy = fmt.Sprintf("%s %s, o.OrderID %s", column, dir, dir)
+
+	return q, ""
+}
+
+func (q orderQuery) sql() string {
+	return orderColumns + q.whereSQL() + " ORDER BY " + q.orderBy
+}
+
+//whereSQL is the WHERE clause of q on orders o, empty when q does not filter
+func (q orderQuery) whereSQL() string {
+	if len(q.where) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(q.where, " AND ")
+}
+
+//pageBounds applies the page size default and limit
+func pageBounds(offset int, pageSize int) (int, int, string) {
+	if offset < 0 {
+		return 0, 0, "offset must not be negative"
+	}
+	size := pageSize
+	if size == 0 {
+		size = defaultPageSize
+	}
+	if size < 0 || size > maxPageSize {
+		return 0, 0, fmt.Sprintf("pageSize must be between 1 and %d", maxPageSize)
+	}
+	return offset, size, ""
+}
+
+//orderBatchSize caps the ids bound to one IN list, loading n orders takes 2*ceil(n/orderBatchSize) queries
+const orderBatchSize = 500
+
+//orderDetailColumns are orderColumns plus the shipping information, for reading orders by id
+const orderDetailColumns = `SELECT
+				o.OrderID,
+				IFNULL(o.CustomerID,'') CustomerID,
+				IFNULL(o.EmployeeID,'') EmployeeID,
+				IFNULL(o.OrderDate,'') OrderDate,
+				o.Status,
+				o.Version,
+				IFNULL(o.Freight,0) Freight,
+				IFNULL(o.ShipName,'') ShipName,
+				IFNULL(o.ShipAddress,'') ShipAddress,
+				IFNULL(o.ShipCity,'') ShipCity,
+				IFNULL(o.ShipRegion,'') ShipRegion,
+				IFNULL(o.ShipPostalCode,'') ShipPostalCode,
+				IFNULL(o.ShipCountry,'') ShipCountry,
+				IFNULL(o.ShipVia,'') ShipVia,
+				IFNULL(o.RequiredDate,'') RequiredDate,
+				IFNULL(o.ShippedDate,'') ShippedDate
+			FROM orders o`
+
+//loadOrders reads the orders in ids with their shipping information, lines and totals, in the order
+//of ids. Headers and lines are fetched a batch at a time, unknown ids are left out.
+func loadOrders(db *database.DbConnection, ids []string) ([]cm.Orders, error) {
+	byID := make(map[string]cm.Orders, len(ids))
+
+	err := inBatches(ids, func(args []interface{}, in string) error {
+		result, err := db.Query(orderDetailColumns+` WHERE o.OrderID IN `+in, args...)
+		if err != nil {
+			return err
+		}
+		defer result.Close()
+
+		for result.Next() {
+			var order cm.Orders
+			var sh cm.Shipping
+			if err := result.Scan(&order.OrderID, &order.CustomerID, &order.EmployeeID, &order.OrderDate, &order.Status, &order.Version, &order.Freight,
+				&sh.ShipName, &sh.ShipAddress, &sh.ShipCity, &sh.ShipRegion, &sh.ShipPostalCode, &sh.ShipCountry, &sh.ShipVia, &sh.RequiredDate, &sh.ShippedDate); err != nil {
+				return err
+			}
+			order.Shipping = &sh
+			byID[order.OrderID] = order
+		}
+		return result.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]cm.Orders, 0, len(byID))
+	for _, id := range ids {
+		if order, ok := byID[id]; ok {
+			orders = append(orders, order)
+			delete(byID, id)
+		}
+	}
+
+	if err := loadOrderDetails(db, orders); err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		computeTotals(&orders[i])
+	}
+
+	return orders, nil
+}
+
+//inBatches calls fn for every orderBatchSize ids with them as args and the matching "(?, ?)" list
+func inBatches(ids []string, fn func(args []interface{}, in string) error) error {
+	for start := 0; start < len(ids); start += orderBatchSize {
+		end := start + orderBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		args := make([]interface{}, end-start)
+		for i, id := range ids[start:end] {
+			args[i] = id
+		}
+		if err := fn(args, "(?"+strings.Repeat(", ?", len(args)-1)+")"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//loadOrderDetails fills OrdersDet of every order, one query per orderBatchSize orders
+func loadOrderDetails(db *database.DbConnection, orders []cm.Orders) error {
+	index := make(map[string]int, len(orders))
+	ids := make([]string, len(orders))
+	for i, o := range orders {
+		index[o.OrderID] = i
+		ids[i] = o.OrderID
+	}
+
+	return inBatches(ids, func(args []interface{}, in string) error {
+		return loadDetailBatch(db, orders, index, args, in)
+	})
+}
+
+func loadDetailBatch(db *database.DbConnection, orders []cm.Orders, index map[string]int, args []interface{}, in string) error {
+	sql := `SELECT
+				order_details.OrderID
+				, products.ProductID
+				, products.ProductName
+				, order_details.UnitPrice
+				, order_details.Quantity
+				, IFNULL(order_details.Discount,0) Discount
+			FROM
+				order_details
+				INNER JOIN products
+					ON (order_details.ProductID = products.ProductID)
+			WHERE order_details.OrderID IN ` + in + `
+			ORDER BY order_details.OrderID, products.ProductID`
+
+	result, err := db.Query(sql, args...)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var line cm.OrdersDetail
+		if err := result.Scan(&line.OrderID, &line.ProductID, &line.ProductName, &line.UnitPrice, &line.Quantity, &line.Discount); err != nil {
+			return err
+		}
+		if i, ok := index[line.OrderID]; ok {
+			orders[i].OrdersDet = append(orders[i].OrdersDet, line)
+		}
+	}
+
+	return result.Err()
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+//roundTrip stands in for the network latency of one query, which is what batching saves
+const roundTrip = 200 * time.Microsecond
+
+var headerColumns = []string{"OrderID", "CustomerID", "EmployeeID", "OrderDate", "Status", "Version", "Freight",
+	"ShipName", "ShipAddress", "ShipCity", "ShipRegion", "ShipPostalCode", "ShipCountry", "ShipVia", "RequiredDate", "ShippedDate"}
+
+var lineColumns = []string{"OrderID", "ProductID", "ProductName", "UnitPrice", "Quantity", "Discount"}
+
+func orderIDs(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = strconv.Itoa(10248 + i)
+	}
+	return ids
+}
+
+func headerRows(ids []string) *sqlmock.Rows {
+	rows := sqlmock.NewRows(headerColumns)
+	for _, id := range ids {
+		rows.AddRow(id, "VINET", "5", "1996-07-04 00:00:00", cm.OrderPaid, 1, "32.38",
+			"Vins", "59 rue de l'Abbaye", "Reims", "", "51100", "France", "3", "1996-08-01 00:00:00", "")
+	}
+	return rows
+}
+
+func lineRows(ids []string) *sqlmock.Rows {
+	rows := sqlmock.NewRows(lineColumns)
+	for _, id := range ids {
+		rows.AddRow(id, "11", "Queso Cabrales", "14.00", 12, "0")
+		rows.AddRow(id, "42", "Singaporean Hokkien Fried Mee", "9.80", 10, "0.15")
+	}
+	return rows
+}
+
+//batches splits ids the way inBatches does
+func batches(ids []string) [][]string {
+	var out [][]string
+	for start := 0; start < len(ids); start += orderBatchSize {
+		end := start + orderBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		out = append(out, ids[start:end])
+	}
+	return out
+}
+
+//expectBatched queues the queries loadOrders makes for ids, headers first and then lines
+func expectBatched(mock sqlmock.Sqlmock, ids []string, delay time.Duration) {
+	for _, b := range batches(ids) {
+		mock.ExpectQuery(`FROM orders o WHERE o.OrderID IN`).WillReturnRows(headerRows(b)).WillDelayFor(delay)
+	}
+	for _, b := range batches(ids) {
+		mock.ExpectQuery(`WHERE order_details.OrderID IN`).WillReturnRows(lineRows(b)).WillDelayFor(delay)
+	}
+}
+
+//expectPerOrder queues the queries of loadOrdersPerOrder for ids
+func expectPerOrder(mock sqlmock.Sqlmock, ids []string, delay time.Duration) {
+	for _, id := range ids {
+		mock.ExpectQuery(`FROM orders o WHERE o.OrderID = \?`).WillReturnRows(headerRows([]string{id})).WillDelayFor(delay)
+		mock.ExpectQuery(`WHERE order_details.OrderID = \?`).WillReturnRows(lineRows([]string{id})).WillDelayFor(delay)
+	}
+}
+
+//loadOrdersPerOrder is the loader loadOrders replaced: for every order its header query
+//followed by its own detail query
+func loadOrdersPerOrder(db *database.DbConnection, ids []string) ([]cm.Orders, error) {
+	var orders []cm.Orders
+	for _, id := range ids {
+		result, err := db.Query(orderDetailColumns+` WHERE o.OrderID = ?`, id)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		var order cm.Orders
+		for result.Next() {
+			var sh cm.Shipping
+			if err := result.Scan(&order.OrderID, &order.CustomerID, &order.EmployeeID, &order.OrderDate, &order.Status, &order.Version, &order.Freight,
+				&sh.ShipName, &sh.ShipAddress, &sh.ShipCity, &sh.ShipRegion, &sh.ShipPostalCode, &sh.ShipCountry, &sh.ShipVia, &sh.RequiredDate, &sh.ShippedDate); err != nil {
+				result.Close()
+				return nil, err
+			}
+			order.Shipping = &sh
+			found = true
+		}
+		result.Close()
+		if !found {
+			continue
+		}
+
+		result, err = db.Query(`SELECT
+				order_details.OrderID
+				, products.ProductID
+				, products.ProductName
+				, order_details.UnitPrice
+				, order_details.Quantity
+				, IFNULL(order_details.Discount,0) Discount
+			FROM
+				order_details
+				INNER JOIN products
+					ON (order_details.ProductID = products.ProductID)
+			WHERE order_details.OrderID = ?`, order.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		for result.Next() {
+			var line cm.OrdersDetail
+			if err := result.Scan(&line.OrderID, &line.ProductID, &line.ProductName, &line.UnitPrice, &line.Quantity, &line.Discount); err != nil {
+				result.Close()
+				return nil, err
+			}
+			order.OrdersDet = append(order.OrdersDet, line)
+		}
+		result.Close()
+
+		computeTotals(&order)
+		orders = append(orders, order)
+	}
+
+	return orders, nil
+}
+
+//queryCounter matches like sqlmock's default matcher and counts the queries it let through
+type queryCounter struct {
+	queries int
+}
+
+func (c *queryCounter) Match(expected, actual string) error {
+	if err := sqlmock.QueryMatcherRegexp.Match(expected, actual); err != nil {
+		return err
+	}
+	c.queries++
+	return nil
+}
+
+func newMockDB(t testing.TB) (*database.DbConnection, sqlmock.Sqlmock, *queryCounter) {
+	counter := &queryCounter{}
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(counter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &database.DbConnection{Db: db}, mock, counter
+}
+
+func TestLoadOrdersQueryCount(t *testing.T) {
+	for _, n := range []int{1, 10, 499, 500, 501, 1000, 1001} {
+		t.Run(strconv.Itoa(n), func(t *testing.T) {
+			db, mock, counter := newMockDB(t)
+			ids := orderIDs(n)
+			expectBatched(mock, ids, 0)
+
+			orders, err := loadOrders(db, ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := 2 * ((n + orderBatchSize - 1) / orderBatchSize)
+			if got := counter.queries; got != want {
+				t.Fatalf("%d orders took %d queries, want 2*ceil(n/%d) = %d", n, got, orderBatchSize, want)
+			}
+			if len(orders) != n {
+				t.Fatalf("loaded %d orders, want %d", len(orders), n)
+			}
+			for _, o := range orders {
+				if len(o.OrdersDet) != 2 || o.Total.String() != "283.68" {
+					t.Fatalf("order %s has %d lines and total %s, want 2 lines and 283.68", o.OrderID, len(o.OrdersDet), o.Total)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadOrdersKeepsIDOrder(t *testing.T) {
+	db, mock, _ := newMockDB(t)
+	ids := []string{"10250", "10248", "99999", "10249"}
+	mock.ExpectQuery(`FROM orders o WHERE o.OrderID IN`).WillReturnRows(headerRows([]string{"10248", "10249", "10250"}))
+	mock.ExpectQuery(`WHERE order_details.OrderID IN`).WillReturnRows(lineRows([]string{"10248", "10249", "10250"}))
+
+	orders, err := loadOrders(db, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, o := range orders {
+		got = append(got, o.OrderID)
+	}
+	if fmt.Sprint(got) != "[10250 10248 10249]" {
+		t.Fatalf("got orders %v, want [10250 10248 10249]", got)
+	}
+}
+
+func BenchmarkLoadOrders(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		ids := orderIDs(n)
+
+		b.Run(fmt.Sprintf("batched/%d", n), func(b *testing.B) {
+			db, mock, _ := newMockDB(b)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectBatched(mock, ids, roundTrip)
+				b.StartTimer()
+
+				if _, err := loadOrders(db, ids); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("per-order/%d", n), func(b *testing.B) {
+			db, mock, _ := newMockDB(b)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				expectPerOrder(mock, ids, roundTrip)
+				b.StartTimer()
+
+				if _, err := loadOrdersPerOrder(db, ids); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
+)
+
+//orderStatuses in lifecycle order
+var orderStatuses = []string{cm.OrderPendingPayment, cm.OrderPaid, cm.OrderShipped, cm.OrderCancelled}
+
+//orderTransitions lists the statuses each status may move to, shipped and cancelled are final
+var orderTransitions = map[string][]string{
+	cm.OrderPendingPayment: {cm.OrderPaid, cm.OrderCancelled},
+	cm.OrderPaid:           {cm.OrderShipped, cm.OrderCancelled},
+}
+
+func knownStatus(status string) bool {
+	for _, s := range orderStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+//orderGuard tells why an order in status cannot take a change, empty when it can
+type orderGuard func(status string) string
+
+//inStatus allows a change only while the order is in one of statuses
+func inStatus(action string, statuses ...string) orderGuard {
+	return func(status string) string {
+		for _, s := range statuses {
+			if s == status {
+				return ""
+			}
+		}
+		return fmt.Sprintf("cannot %s of an order that is %s", action, status)
+	}
+}
+
+//movesTo allows a change only when the order may move from its status to to
+func movesTo(to string) orderGuard {
+	return func(status string) string {
+		if canTransition(status, to) {
+			return ""
+		}
+		if len(orderTransitions[status]) == 0 {
+			return fmt.Sprintf("order is %s, its status cannot change any more", status)
+		}
+		return fmt.Sprintf("order cannot go from %s to %s, only to %s", status, to, strings.Join(orderTransitions[status], " or "))
+	}
+}
+
+//setStatus moves the order to status inside tx and records the change, from is empty for a new order
+func setStatus(tx *database.DbConnection, orderID string, from string, to string, reason string) error {
+	now := time.Now().Format(orderDateLayouts[0])
+
+	sql := "UPDATE orders SET Status = ? WHERE OrderID = ?"
+	args := []interface{}{to, orderID}
+	if to == cm.OrderShipped {
+		sql = "UPDATE orders SET Status = ?, ShippedDate = IFNULL(ShippedDate, ?) WHERE OrderID = ?"
+		args = []interface{}{to, now, orderID}
+	}
+	if from != "" {
+		if _, err := tx.ExecTx(sql, args...); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecTx("INSERT INTO order_status_history (OrderID, FromStatus, ToStatus, Reason, ChangedAt) VALUES (?, ?, ?, ?, ?)",
+		orderID, nullable(from), to, nullable(reason), now)
+	return err
+}
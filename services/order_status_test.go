@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{cm.OrderPendingPayment, cm.OrderPaid, true},
+		{cm.OrderPendingPayment, cm.OrderCancelled, true},
+		{cm.OrderPendingPayment, cm.OrderShipped, false},
+		{cm.OrderPendingPayment, cm.OrderPendingPayment, false},
+		{cm.OrderPaid, cm.OrderShipped, true},
+		{cm.OrderPaid, cm.OrderCancelled, true},
+		{cm.OrderPaid, cm.OrderPendingPayment, false},
+		{cm.OrderShipped, cm.OrderCancelled, false},
+		{cm.OrderShipped, cm.OrderPaid, false},
+		{cm.OrderCancelled, cm.OrderPaid, false},
+		{cm.OrderCancelled, cm.OrderPendingPayment, false},
+		{"", cm.OrderPaid, false},
+		{cm.OrderPaid, "refunded", false},
+	}
+	for _, tt := range tests {
+		if got := canTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestMovesTo(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{cm.OrderPendingPayment, cm.OrderPaid, ""},
+		{cm.OrderPaid, cm.OrderPendingPayment, "order cannot go from " + cm.OrderPaid + " to " + cm.OrderPendingPayment +
+			", only to " + cm.OrderShipped + " or " + cm.OrderCancelled},
+		{cm.OrderShipped, cm.OrderCancelled, "order is " + cm.OrderShipped + ", its status cannot change any more"},
+		{cm.OrderCancelled, cm.OrderPaid, "order is " + cm.OrderCancelled + ", its status cannot change any more"},
+	}
+	for _, tt := range tests {
+		if got := movesTo(tt.to)(tt.from); got != tt.want {
+			t.Errorf("movesTo(%q)(%q) = %q, want %q", tt.to, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestKnownStatus(t *testing.T) {
+	for _, s := range orderStatuses {
+		if !knownStatus(s) {
+			t.Errorf("knownStatus(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "refunded", "PAID"} {
+		if knownStatus(s) {
+			t.Errorf("knownStatus(%q) = true", s)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	"github.com/shopspring/decimal"
+)
+
+//moneyPlaces is the precision line amounts are rounded to
+const moneyPlaces = 2
+
+var one = decimal.New(1, 0)
+
+//computeTotals fills the amount of every line and the subtotal and total of order
+func computeTotals(order *cm.Orders) {
+	subtotal := decimal.Zero
+	for i, line := range order.OrdersDet {
+		amount := lineAmount(line)
+		order.OrdersDet[i].Amount = cm.NewMoney(amount)
+		subtotal = subtotal.Add(amount)
+	}
+
+	order.Subtotal = cm.NewMoney(subtotal)
+	order.Total = cm.NewMoney(subtotal.Add(order.Freight.Decimal))
+}
+
+//lineAmount is UnitPrice * Quantity * (1 - Discount) rounded to moneyPlaces
+func lineAmount(line cm.OrdersDetail) decimal.Decimal {
+	return line.UnitPrice.
+		Mul(decimal.New(int64(line.Quantity), 0)).
+		Mul(one.Sub(line.Discount.Decimal)).
+		Round(moneyPlaces)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+
+	"github.com/shopspring/decimal"
+)
+
+func testLine(price string, quantity int, discount string) cm.OrdersDetail {
+	return cm.OrdersDetail{
+		UnitPrice: cm.NewMoney(decimal.RequireFromString(price)),
+		Quantity:  quantity,
+		Discount:  cm.NewMoney(decimal.RequireFromString(discount)),
+	}
+}
+
+func TestLineAmount(t *testing.T) {
+	tests := []struct {
+		name string
+		line cm.OrdersDetail
+		want string
+	}{
+		{"no discount", testLine("14.00", 12, "0"), "168"},
+		{"discount", testLine("9.80", 10, "0.15"), "83.3"},
+		{"rounded to cents", testLine("19.99", 3, "0.05"), "56.97"},
+		{"half rounds away from zero", testLine("0.125", 1, "0"), "0.13"},
+		{"exact where float64 is not", testLine("0.1", 3, "0"), "0.3"},
+		{"full discount", testLine("42.00", 5, "1"), "0"},
+		{"no quantity", testLine("42.00", 0, "0.1"), "0"},
+	}
+	for _, tt := range tests {
+		if got := lineAmount(tt.line); !got.Equal(decimal.RequireFromString(tt.want)) {
+			t.Errorf("%s: lineAmount = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeTotals(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []cm.OrdersDetail
+		freight  string
+		amounts  []string
+		subtotal string
+		total    string
+	}{
+		{
+			name:     "lines and freight",
+			lines:    []cm.OrdersDetail{testLine("14.00", 12, "0"), testLine("9.80", 10, "0.15")},
+			freight:  "32.38",
+			amounts:  []string{"168", "83.3"},
+			subtotal: "251.3",
+			total:    "283.68",
+		},
+		{
+			name:     "sum of rounded lines",
+			lines:    []cm.OrdersDetail{testLine("0.125", 1, "0"), testLine("0.125", 1, "0")},
+			freight:  "0",
+			amounts:  []string{"0.13", "0.13"},
+			subtotal: "0.26",
+			total:    "0.26",
+		},
+		{
+			name:     "no lines",
+			freight:  "11.61",
+			subtotal: "0",
+			total:    "11.61",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := cm.Orders{OrdersDet: tt.lines, Freight: cm.NewMoney(decimal.RequireFromString(tt.freight))}
+			computeTotals(&order)
+
+			for i, want := range tt.amounts {
+				if got := order.OrdersDet[i].Amount; !got.Equal(decimal.RequireFromString(want)) {
+					t.Errorf("line %d amount %s, want %s", i, got, want)
+				}
+			}
+			if !order.Subtotal.Equal(decimal.RequireFromString(tt.subtotal)) {
+				t.Errorf("subtotal %s, want %s", order.Subtotal, tt.subtotal)
+			}
+			if !order.Total.Equal(decimal.RequireFromString(tt.total)) {
+				t.Errorf("total %s, want %s", order.Total, tt.total)
+			}
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	order := cm.Orders{OrdersDet: []cm.OrdersDetail{testLine("9.80", 10, "0.15")}, Freight: cm.NewMoney(decimal.RequireFromString("3.5"))}
+	computeTotals(&order)
+
+	b, err := json.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"UnitPrice":9.8,`, `"Discount":0.15,`, `"Amount":83.3}`, `"freight":3.5,`, `"subtotal":83.3,`, `"total":86.8,`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("%s does not contain %s", b, want)
+		}
+	}
+
+	var line cm.OrdersDetail
+	if err := json.Unmarshal([]byte(`{"UnitPrice":"0.10","Discount":0.05}`), &line); err != nil {
+		t.Fatal(err)
+	}
+	if !line.UnitPrice.Equal(decimal.RequireFromString("0.1")) || !line.Discount.Equal(decimal.RequireFromString("0.05")) || !line.PriceGiven {
+		t.Errorf("decoded %+v", line)
+	}
+
+	if b, _ := json.Marshal(decimal.RequireFromString("1.5")); string(b) != `"1.5"` {
+		t.Errorf("plain decimals are written %s, the global quoting setting was changed", b)
+	}
+}
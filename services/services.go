@@ -6,18 +6,69 @@ import (
 	"time"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	database "Hanif_Aulia_Sabri-MyTrip/git/order/conn"
 )
 
 //SubscriberServices is service definition
 type PaymentServices interface {
 	OrderHandler(context.Context, cm.Message) cm.Message
+	CreateOrderHandler(context.Context, cm.Orders) cm.Message
+	ListOrdersHandler(context.Context, cm.OrderFilter) cm.OrderList
+	UpdateOrderLinesHandler(context.Context, cm.OrderUpdate) cm.Message
+	UpdateShippingHandler(context.Context, cm.OrderUpdate) cm.Message
+	CancelOrderHandler(context.Context, cm.OrderUpdate) cm.Message
+	TransitionOrderHandler(context.Context, cm.OrderUpdate) cm.Message
+	OrderHistoryHandler(context.Context, cm.Message) cm.StatusHistory
+	InvoiceHandler(context.Context, cm.InvoiceRequest) cm.Document
+	IssueInvoiceHandler(context.Context, cm.InvoiceRequest) cm.Document
+	ExportOrdersHandler(context.Context, cm.OrderExport) cm.Export
+	ExportCustomersHandler(context.Context, cm.CustomerExport) cm.Export
 	CustomerHandler(context.Context, cm.Customers) cm.Customers
+	GetCustomerHandler(context.Context, cm.Customers) cm.CustomerResult
+	SearchCustomersHandler(context.Context, cm.CustomerSearch) cm.CustomerList
+	CustomerOrdersHandler(context.Context, cm.OrderFilter) cm.CustomerOrders
+	FindDuplicatesHandler(context.Context, cm.DuplicateSearch) cm.DuplicateList
+	MergeCustomerHandler(context.Context, cm.CustomerMerge) cm.MergeResult
+	CreateCustomerHandler(context.Context, cm.Customers) cm.CustomerResult
+	UpdateCustomerHandler(context.Context, cm.Customers) cm.CustomerResult
+	PatchCustomerHandler(context.Context, cm.CustomerPatch) cm.CustomerResult
+	DeleteCustomerHandler(context.Context, cm.Customers) cm.CustomerResult
 	FastPayHandler(context.Context, cm.FastPayRequest) cm.FastPayResponse
 	CallHandler(context.Context, cm.FastPayRequest) cm.FastPayResponse
 	TripsHandler(context.Context, cm.MyTripsrequest) cm.MytripsResponse
+	HealthHandler(context.Context) cm.Health
+	ReadinessHandler(context.Context) cm.Health
 }
 
-type PaymentService struct{}
+//Service areas, each one reads and writes through the datasource configured for it
+const (
+	AreaOrders    = "orders"
+	AreaCustomers = "customers"
+	AreaFastPay   = "fastpay"
+	AreaTrips     = "trips"
+)
+
+//PaymentService holds the resources shared by every handler
+type PaymentService struct {
+	datasources map[string]*database.DbConnection
+	conf        cm.Configuration
+}
+
+//NewPaymentService builds the service on top of already opened connection pools,
+//conf decides which of them serves each area
+func NewPaymentService(datasources map[string]*database.DbConnection, conf cm.Configuration) PaymentService {
+	return PaymentService{datasources: datasources, conf: conf}
+}
+
+//db returns the connection pool serving area, it panics when none is configured
+func (s PaymentService) db(area string) *database.DbConnection {
+	name := s.conf.DatasourceFor(area)
+	db, ok := s.datasources[name]
+	if !ok {
+		panic(fmt.Sprintf("no datasource %q configured for %s", name, area))
+	}
+	return db
+}
 
 type ServiceMiddleware func(PaymentServices) PaymentServices
 
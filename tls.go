@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/transport"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+//certCheckInterval bounds how often the certificate files are checked for changes during handshakes
+const certCheckInterval = 10 * time.Second
+
+//certLoader serves the server certificate and reloads it when its files change on disk
+type certLoader struct {
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	certFile string
+	keyFile  string
+	modTime  time.Time
+	checked  time.Time
+}
+
+//newTLSConfig builds the listener configuration, client certificates are requested while the
+//live configuration has tls.clientAuth entries and verified per route prefix by requireClientCert
+func newTLSConfig(c cm.TLSConfig) (*tls.Config, *certLoader, error) {
+	l := &certLoader{}
+	if err := l.load(c.CertFile, c.KeyFile); err != nil {
+		return nil, nil, err
+	}
+
+	minVersion := uint16(tls.VersionTLS12)
+	if v, ok := cm.TLSVersions[c.MinVersion]; ok {
+		minVersion = v
+	}
+
+	conf := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: l.GetCertificate,
+	}
+	//decided per handshake so a reload that adds or drops clientAuth entries applies to new connections
+	requested := conf.Clone()
+	requested.ClientAuth = tls.RequestClientCert
+	conf.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		if len(cm.Current().TLS.ClientAuth) == 0 {
+			return nil, nil
+		}
+		return requested, nil
+	}
+
+	return conf, l, nil
+}
+
+func lastModified(files ...string) (time.Time, error) {
+	var newest time.Time
+	for _, fn := range files {
+		fi, err := os.Stat(fn)
+		if err != nil {
+			return newest, err
+		}
+		if fi.ModTime().After(newest) {
+			newest = fi.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+func (l *certLoader) load(certFile, keyFile string) error {
+	modTime, err := lastModified(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+
+	l.cert = &cert
+	l.certFile, l.keyFile = certFile, keyFile
+	l.modTime = modTime
+	l.checked = time.Now()
+	return nil
+}
+
+//Reload loads the certificate named by the live configuration if its files moved or changed
+func (l *certLoader) Reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reload()
+}
+
+func (l *certLoader) reload() {
+	c := cm.Current().TLS
+	l.checked = time.Now()
+
+	modTime, err := lastModified(c.CertFile, c.KeyFile)
+	if err != nil {
+		log.WithField("error", err).Warn("Unable to check certificate files, keeping the current certificate")
+		return
+	}
+	if c.CertFile == l.certFile && c.KeyFile == l.keyFile && !modTime.After(l.modTime) {
+		return
+	}
+
+	if err := l.load(c.CertFile, c.KeyFile); err != nil {
+		log.WithField("error", err).Error("Certificate reload rejected, keeping the current certificate")
+		return
+	}
+	log.WithField("certFile", c.CertFile).Info("Certificate reloaded")
+}
+
+func (l *certLoader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if time.Since(l.checked) > certCheckInterval {
+		l.reload()
+	}
+	return l.cert, nil
+}
+
+//clientAuthRule requires a client certificate chaining to roots on every path under prefix
+type clientAuthRule struct {
+	prefix string
+	roots  *x509.CertPool
+}
+
+//loadClientAuth reads the CA bundle of every tls.clientAuth entry, prefixes are made absolute with rootUrl
+func loadClientAuth(conf cm.Configuration) ([]clientAuthRule, error) {
+	var rules []clientAuthRule
+	for _, ca := range conf.TLS.ClientAuth {
+		pem, err := ioutil.ReadFile(ca.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificate found", ca.CAFile)
+		}
+
+		rules = append(rules, clientAuthRule{prefix: conf.RootURL + ca.Prefix, roots: roots})
+	}
+	return rules, nil
+}
+
+//requireClientCert rejects requests under a protected prefix without a client certificate signed by its CA
+func requireClientCert(rules []clientAuthRule, next http.Handler) http.Handler {
+	if len(rules) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, rule := range rules {
+			if r.URL.Path != rule.prefix && !strings.HasPrefix(r.URL.Path, rule.prefix+"/") {
+				continue
+			}
+
+			if err := verifyClientCert(r, rule.roots); err != nil {
+				log.WithField("path", r.URL.Path).WithField("remote", r.RemoteAddr).WithField("error", err).Warn("Client certificate rejected")
+
+				transport.EncodeError(w, http.StatusForbidden, cm.CodeForbidden, "Client certificate rejected")
+				return
+			}
+			break
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func verifyClientCert(r *http.Request, roots *x509.CertPool) error {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return fmt.Errorf("no client certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range r.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(c)
+	}
+
+	_, err := r.TLS.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
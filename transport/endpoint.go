@@ -4,6 +4,7 @@ import (
 	"context"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	ex "Hanif_Aulia_Sabri-MyTrip/git/order/error"
 	"Hanif_Aulia_Sabri-MyTrip/git/order/services"
 
 	log "github.com/Sirupsen/logrus"
@@ -11,10 +12,28 @@ import (
 	"github.com/go-kit/kit/endpoint"
 )
 
-func invalidRequest() cm.Message {
+//invalidRequest answers a request the endpoint cannot handle, the remark of a failed decoder is kept
+func invalidRequest(request interface{}) cm.Message {
+	switch r := request.(type) {
+	case cm.Message:
+		if r.Result != nil {
+			return r
+		}
+	case *ex.AppError:
+		if r.Remark == "" {
+			break
+		}
+		return cm.Message{
+			Result: &cm.Result{
+				Code:   cm.CodeInvalid,
+				Remark: r.Remark,
+			},
+		}
+	}
+
 	return cm.Message{
 		Result: &cm.Result{
-			Code:   99,
+			Code:   cm.CodeInvalid,
 			Remark: "Invalid Request",
 		},
 	}
@@ -27,7 +46,128 @@ func OrderEndpoint(svc services.PaymentServices) endpoint.Endpoint {
 			return svc.OrderHandler(ctx, req), nil
 		}
 		log.WithField("Error", request).Info("Request in in unkwon format")
-		return invalidRequest(), nil
+		return invalidRequest(request), nil
+	}
+}
+
+func CreateOrderEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.Orders); ok {
+			return svc.CreateOrderHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func ListOrdersEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderFilter); ok {
+			return svc.ListOrdersHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func UpdateOrderLinesEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderUpdate); ok {
+			return svc.UpdateOrderLinesHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func UpdateShippingEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderUpdate); ok {
+			return svc.UpdateShippingHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func CancelOrderEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderUpdate); ok {
+			return svc.CancelOrderHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func TransitionOrderEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderUpdate); ok {
+			return svc.TransitionOrderHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func OrderHistoryEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.Message); ok {
+			return svc.OrderHistoryHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func InvoiceEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.InvoiceRequest); ok {
+			return svc.InvoiceHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func IssueInvoiceEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.InvoiceRequest); ok {
+			return svc.IssueInvoiceHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func ExportOrdersEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderExport); ok {
+			return svc.ExportOrdersHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func ExportCustomersEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.CustomerExport); ok {
+			return svc.ExportCustomersHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
 	}
 }
 
@@ -38,7 +178,106 @@ func CustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
 			return svc.CustomerHandler(ctx, req), nil
 		}
 		log.WithField("Error", request).Info("Request in in unkwon format")
-		return invalidRequest(), nil
+		return invalidRequest(request), nil
+	}
+}
+
+func GetCustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.Customers); ok {
+			return svc.GetCustomerHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func SearchCustomersEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.CustomerSearch); ok {
+			return svc.SearchCustomersHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func CustomerOrdersEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.OrderFilter); ok {
+			return svc.CustomerOrdersHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func FindDuplicatesEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.DuplicateSearch); ok {
+			return svc.FindDuplicatesHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func MergeCustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.CustomerMerge); ok {
+			return svc.MergeCustomerHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func CreateCustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.Customers); ok {
+			return svc.CreateCustomerHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func UpdateCustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.Customers); ok {
+			return svc.UpdateCustomerHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func PatchCustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.CustomerPatch); ok {
+			return svc.PatchCustomerHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
+	}
+}
+
+func DeleteCustomerEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+
+		if req, ok := request.(cm.Customers); ok {
+			return svc.DeleteCustomerHandler(ctx, req), nil
+		}
+		log.WithField("Error", request).Info("Request in in unkwon format")
+		return invalidRequest(request), nil
 	}
 }
 
@@ -49,7 +288,7 @@ func FastEndpoint(svc services.PaymentServices) endpoint.Endpoint {
 			return svc.FastPayHandler(ctx, req), nil
 		}
 		log.WithField("Error", request).Info("Request in in unkwon format")
-		return invalidRequest(), nil
+		return invalidRequest(request), nil
 	}
 }
 
@@ -60,7 +299,7 @@ func CallEndpoint(svc services.PaymentServices) endpoint.Endpoint {
 			return svc.CallHandler(ctx, req), nil
 		}
 		log.WithField("Error", request).Info("Request in in unkwon format")
-		return invalidRequest(), nil
+		return invalidRequest(request), nil
 	}
 }
 
@@ -71,6 +310,18 @@ func TripsEndpoint(svc services.PaymentServices) endpoint.Endpoint {
 			return svc.TripsHandler(ctx, req), nil
 		}
 		log.WithField("Error", request).Info("Request in in unkwon format")
-		return invalidRequest(), nil
+		return invalidRequest(request), nil
+	}
+}
+
+func HealthEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return svc.HealthHandler(ctx), nil
+	}
+}
+
+func ReadinessEndpoint(svc services.PaymentServices) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		return svc.ReadinessHandler(ctx), nil
 	}
 }
@@ -0,0 +1,32 @@
+package transport
+
+import (
+	"errors"
+	"testing"
+
+	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	ex "Hanif_Aulia_Sabri-MyTrip/git/order/error"
+)
+
+func TestInvalidRequest(t *testing.T) {
+	decoded := cm.Message{Result: &cm.Result{Code: cm.CodeNotFound, Remark: "order 7 not found"}}
+
+	tests := []struct {
+		name    string
+		request interface{}
+		code    int
+		remark  string
+	}{
+		{"decoder message", decoded, cm.CodeNotFound, "order 7 not found"},
+		{"decoder error", ex.Error(errors.New("unexpected EOF"), 100).Rem("Failed decoding json message"), cm.CodeInvalid, "Failed decoding json message"},
+		{"decoder error without remark", ex.Errorc(100), cm.CodeInvalid, "Invalid Request"},
+		{"message without result", cm.Message{OrderID: "7"}, cm.CodeInvalid, "Invalid Request"},
+		{"unknown type", 42, cm.CodeInvalid, "Invalid Request"},
+	}
+	for _, tt := range tests {
+		got := invalidRequest(tt.request)
+		if got.Result == nil || got.Result.Code != tt.code || got.Result.Remark != tt.remark {
+			t.Errorf("%s: invalidRequest() = %+v, want code %d remark %q", tt.name, got.Result, tt.code, tt.remark)
+		}
+	}
+}
@@ -3,15 +3,20 @@ package transport
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
+	"strconv"
+	"strings"
 
 	cm "Hanif_Aulia_Sabri-MyTrip/git/order/common"
+	"Hanif_Aulia_Sabri-MyTrip/git/order/export"
 
 	ex "Hanif_Aulia_Sabri-MyTrip/git/order/error"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/gorilla/mux"
 )
 
 func DecodeRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -43,6 +48,33 @@ func DecodeRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	//return nil, nil
 }
 
+func DecodeCreateOrderRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var body []byte
+
+	requestDump, err := httputil.DumpRequest(r, true)
+	if err != nil {
+		log.WithField("error", err).Error("Exception caught")
+	}
+	log.Debug(string(requestDump))
+
+	//decode request body
+	body, err = ioutil.ReadAll(r.Body)
+
+	log.WithField("info", string(body[:])).Info("Decode Request Create Order API")
+
+	if err != nil {
+		return ex.Error(err, 100).Rem("Unable to read request body"), nil
+	}
+
+	var request cm.Orders
+
+	if err = json.Unmarshal(body, &request); err != nil {
+		return ex.Error(err, 100).Rem("Failed decoding json message"), nil
+	}
+
+	return request, nil
+}
+
 func DecodeCustomerRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	var body []byte
 
@@ -136,6 +168,259 @@ func DecodeTripRequest(ctx context.Context, r *http.Request) (interface{}, error
 	//return nil, nil
 }
 
+//DecodeOrderIDRequest reads the order from the {orderID} path parameter
+func DecodeOrderIDRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return cm.Message{OrderID: mux.Vars(r)["orderID"]}, nil
+}
+
+//DecodeOrderUpdateRequest reads the change from the body and the order from the {orderID} path parameter.
+//The version may come from the If-Match header, it takes precedence over the body.
+func DecodeOrderUpdateRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var body []byte
+
+	requestDump, err := httputil.DumpRequest(r, true)
+	if err != nil {
+		log.WithField("error", err).Error("Exception caught")
+	}
+	log.Debug(string(requestDump))
+
+	//decode request body
+	body, err = ioutil.ReadAll(r.Body)
+
+	log.WithField("info", string(body[:])).Info("Decode Request Update Order API")
+
+	if err != nil {
+		return ex.Error(err, 100).Rem("Unable to read request body"), nil
+	}
+
+	var request cm.OrderUpdate
+
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &request); err != nil {
+			return ex.Error(err, 100).Rem("Failed decoding json message"), nil
+		}
+	}
+	request.OrderID = mux.Vars(r)["orderID"]
+
+	if tag := r.Header.Get("If-Match"); tag != "" {
+		if request.Version, err = parseETag(tag); err != nil {
+			return ex.Error(err, 100).Rem("If-Match must be the ETag of the order"), nil
+		}
+	}
+
+	return request, nil
+}
+
+//DecodeInvoiceRequest reads the order from the {orderID} path parameter and ?format=html|pdf, html by default
+func DecodeInvoiceRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = cm.FormatHTML
+	}
+	return cm.InvoiceRequest{OrderID: mux.Vars(r)["orderID"], Format: format}, nil
+}
+
+//DecodeListOrdersRequest reads the order filter from the query string, e.g.
+//?customerID=ALFKI&dateFrom=1997-01-01&sort=-orderDate&pageSize=50&offset=100&expand=details
+func DecodeListOrdersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+
+	request := cm.OrderFilter{
+		CustomerID: q.Get("customerID"),
+		EmployeeID: q.Get("employeeID"),
+		ProductID:  q.Get("productID"),
+		Status:     q.Get("status"),
+		DateFrom:   q.Get("dateFrom"),
+		DateTo:     q.Get("dateTo"),
+		Sort:       q.Get("sort"),
+		Expand:     q.Get("expand") == "details" || q.Get("expand") == "true",
+	}
+
+	var err error
+	if v := q.Get("offset"); v != "" {
+		if request.Offset, err = strconv.Atoi(v); err != nil {
+			return ex.Error(err, 100).Rem("offset must be a number"), nil
+		}
+	}
+	if v := q.Get("pageSize"); v != "" {
+		if request.PageSize, err = strconv.Atoi(v); err != nil {
+			return ex.Error(err, 100).Rem("pageSize must be a number"), nil
+		}
+	}
+
+	return request, nil
+}
+
+//DecodeExportOrdersRequest takes the filters of DecodeListOrdersRequest plus format=csv|xlsx, csv by default
+func DecodeExportOrdersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	filter, err := DecodeListOrdersRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := filter.(cm.OrderFilter)
+	if !ok {
+		return filter, nil
+	}
+
+	return cm.OrderExport{Format: exportFormat(r), OrderFilter: f}, nil
+}
+
+//DecodeSearchCustomersRequest reads the search from the query string, e.g.
+//?q=maria+ber&match=contains&country=Germany&pageSize=20&offset=40
+func DecodeSearchCustomersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+
+	request := cm.CustomerSearch{
+		Q:     q.Get("q"),
+		Match: q.Get("match"),
+		CustomerFilter: cm.CustomerFilter{
+			Country: q.Get("country"),
+			City:    q.Get("city"),
+		},
+	}
+
+	var err error
+	if v := q.Get("offset"); v != "" {
+		if request.Offset, err = strconv.Atoi(v); err != nil {
+			return ex.Error(err, 100).Rem("offset must be a number"), nil
+		}
+	}
+	if v := q.Get("pageSize"); v != "" {
+		if request.PageSize, err = strconv.Atoi(v); err != nil {
+			return ex.Error(err, 100).Rem("pageSize must be a number"), nil
+		}
+	}
+
+	return request, nil
+}
+
+//DecodeCustomerOrdersRequest takes the filters of DecodeListOrdersRequest, the customer
+//comes from the {customerID} path parameter
+func DecodeCustomerOrdersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	filter, err := DecodeListOrdersRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := filter.(cm.OrderFilter)
+	if !ok {
+		return filter, nil
+	}
+
+	f.CustomerID = mux.Vars(r)["customerID"]
+	return f, nil
+}
+
+//DecodeExportCustomersRequest reads ?country=Germany&city=Berlin&format=xlsx
+func DecodeExportCustomersRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+
+	return cm.CustomerExport{
+		Format: exportFormat(r),
+		CustomerFilter: cm.CustomerFilter{
+			Country: q.Get("country"),
+			City:    q.Get("city"),
+		},
+	}, nil
+}
+
+func exportFormat(r *http.Request) string {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f
+	}
+	return cm.FormatCSV
+}
+
+//DecodeCustomerUpdateRequest reads the whole customer from the body, its CustomerID from the {customerID} path parameter
+func DecodeCustomerUpdateRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	request, err := DecodeCustomerRequest(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := request.(cm.Customers)
+	if !ok {
+		return request, nil
+	}
+
+	id := mux.Vars(r)["customerID"]
+	if c.CustomerID != "" && c.CustomerID != id {
+		return ex.Errorc(100).Rem("CustomerID cannot be changed"), nil
+	}
+	c.CustomerID = id
+	return c, nil
+}
+
+//DecodeCustomerPatchRequest reads the fields to change from the body, fields left out are kept
+func DecodeCustomerPatchRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(r.Body)
+
+	log.WithField("info", string(body[:])).Info("Decode Request Patch Customer API")
+
+	if err != nil {
+		return ex.Error(err, 100).Rem("Unable to read request body"), nil
+	}
+
+	var request cm.CustomerPatch
+
+	if err = json.Unmarshal(body, &request); err != nil {
+		return ex.Error(err, 100).Rem("Failed decoding json message"), nil
+	}
+	request.CustomerID = mux.Vars(r)["customerID"]
+
+	return request, nil
+}
+
+//DecodeFindDuplicatesRequest reads the compared customers from the query string, e.g.
+//?minScore=0.8&country=Germany
+func DecodeFindDuplicatesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	q := r.URL.Query()
+
+	request := cm.DuplicateSearch{
+		CustomerFilter: cm.CustomerFilter{
+			Country: q.Get("country"),
+			City:    q.Get("city"),
+		},
+	}
+
+	if v := q.Get("minScore"); v != "" {
+		var err error
+		if request.MinScore, err = strconv.ParseFloat(v, 64); err != nil {
+			return ex.Error(err, 100).Rem("minScore must be a number"), nil
+		}
+	}
+
+	return request, nil
+}
+
+//DecodeMergeCustomerRequest reads the duplicate from the body, the survivor is the {customerID} path parameter
+func DecodeMergeCustomerRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	body, err := ioutil.ReadAll(r.Body)
+
+	log.WithField("info", string(body[:])).Info("Decode Request Merge Customer API")
+
+	if err != nil {
+		return ex.Error(err, 100).Rem("Unable to read request body"), nil
+	}
+
+	var request cm.CustomerMerge
+
+	if err = json.Unmarshal(body, &request); err != nil {
+		return ex.Error(err, 100).Rem("Failed decoding json message"), nil
+	}
+	request.SurvivorID = mux.Vars(r)["customerID"]
+
+	return request, nil
+}
+
+//DecodeCustomerIDRequest reads the customer from the {customerID} path parameter
+func DecodeCustomerIDRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return cm.Customers{CustomerID: mux.Vars(r)["customerID"]}, nil
+}
+
+//DecodeEmptyRequest is used by endpoints that take no request body
+func DecodeEmptyRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return nil, nil
+}
+
 func EncodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	var body []byte
 	body, err := json.Marshal(&response)
@@ -157,9 +442,118 @@ func EncodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 		}
 	} else if _, ok := response.(int); ok {
 		w.WriteHeader(http.StatusOK)
+	} else if r, ok := response.(resultCoder); ok {
+		w.WriteHeader(HTTPStatus(r.ResultCode()))
 	}
 
 	_, err = w.Write(body)
 
 	return err
 }
+
+//EncodeExportResponse streams an accepted export as a file download, a rejected one is answered as JSON.
+//Once rows are sent the status cannot change any more, so a failure midway is only logged and
+//leaves a truncated file.
+func EncodeExportResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	e, ok := response.(cm.Export)
+	if !ok || e.Code != cm.CodeSuccess {
+		return EncodeResponse(ctx, w, response)
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(e.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.Name+"."+e.Format))
+
+	if err := export.Write(e, w); err != nil {
+		log.WithField("error", err).Error("Export of " + e.Name + " failed")
+	}
+	return nil
+}
+
+//EncodeDocumentResponse answers a rendered cm.Document as is, one that failed as JSON
+func EncodeDocumentResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	d, ok := response.(cm.Document)
+	if !ok || d.Code != cm.CodeSuccess {
+		return EncodeResponse(ctx, w, response)
+	}
+
+	w.Header().Set("Content-Type", d.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", d.Name))
+	w.Header().Set("Content-Length", strconv.Itoa(len(d.Body)))
+
+	_, err := w.Write(d.Body)
+	return err
+}
+
+//EncodeDeprecatedResponse marks the response of a path kept only for old clients, successor
+//names the path replacing it
+func EncodeDeprecatedResponse(successor string) func(context.Context, http.ResponseWriter, interface{}) error {
+	return func(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Warning", fmt.Sprintf(`299 - "Deprecated API, use %s"`, successor))
+		return EncodeResponse(ctx, w, response)
+	}
+}
+
+//resultCoder is implemented by responses carrying one of the cm.Code result codes
+type resultCoder interface {
+	ResultCode() int
+}
+
+//HTTPStatus maps a result code to the HTTP status answered with it. A zero code means
+//the handler gave up without setting one, it is answered as an internal error.
+func HTTPStatus(code int) int {
+	switch code {
+	case cm.CodeSuccess:
+		return http.StatusOK
+	case cm.CodeInvalid:
+		return http.StatusBadRequest
+	case cm.CodeForbidden:
+		return http.StatusForbidden
+	case cm.CodeNotFound, cm.CodePathNotFound:
+		return http.StatusNotFound
+	case cm.CodeMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case cm.CodeConflict, cm.CodeIllegalStatus:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+//EncodeOrderResponse sends the version of the order as its ETag, to be echoed in If-Match when updating it
+func EncodeOrderResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if res, ok := response.(cm.Message); ok && res.Orders != nil && res.Orders.Version > 0 {
+		w.Header().Set("ETag", orderETag(res.Orders.Version))
+	}
+
+	return EncodeResponse(ctx, w, response)
+}
+
+func orderETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+//parseETag accepts "3", W/"3" and a bare 3
+func parseETag(tag string) (int, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	return strconv.Atoi(strings.Trim(tag, `"`))
+}
+
+//EncodeHealthResponse answers 503 when a dependency is down so load balancers take the instance out
+func EncodeHealthResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if h, ok := response.(cm.Health); ok && h.Status != cm.StatusUp {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+//EncodeError writes a cm.Result with the given HTTP status, for failures outside go-kit endpoints
+func EncodeError(w http.ResponseWriter, status int, code int, remark string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(cm.Result{Code: code, Remark: remark})
+}
@@ -0,0 +1,40 @@
+package transport
+
+import "testing"
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    int
+		invalid bool
+	}{
+		{`"3"`, 3, false},
+		{`W/"3"`, 3, false},
+		{`3`, 3, false},
+		{` "12" `, 12, false},
+		{`"0"`, 0, false},
+		{``, 0, true},
+		{`""`, 0, true},
+		{`*`, 0, true},
+		{`"abc"`, 0, true},
+		{`"3", "4"`, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseETag(tt.tag)
+		if (err != nil) != tt.invalid || got != tt.want {
+			t.Errorf("parseETag(%q) = %d, %v, want %d with invalid %v", tt.tag, got, err, tt.want, tt.invalid)
+		}
+	}
+}
+
+func TestOrderETagRoundTrip(t *testing.T) {
+	for _, version := range []int{1, 7, 1024} {
+		got, err := parseETag(orderETag(version))
+		if err != nil || got != version {
+			t.Errorf("parseETag(orderETag(%d)) = %d, %v", version, got, err)
+		}
+		if got, err := parseETag("W/" + orderETag(version)); err != nil || got != version {
+			t.Errorf("parseETag(W/%s) = %d, %v", orderETag(version), got, err)
+		}
+	}
+}